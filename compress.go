@@ -1,8 +1,8 @@
 package kdb
 
 import (
-	"bytes"
 	"encoding/binary"
+	"math"
 )
 
 // Compress b using Q IPC compression
@@ -88,26 +88,38 @@ func min32(a, b int32) int32 {
 	return a
 }
 
-// Uncompress byte array compressed with Q IPC compression
-func Uncompress(b []byte) (dst []byte) {
+// Uncompress byte array compressed with Q IPC compression.
+// b is the compressed message without its 8 byte header.
+// Returns ErrBadMsg if b is malformed.
+func Uncompress(b []byte) (dst []byte, err error) {
 	if len(b) < 4+1 {
-		return b
+		return nil, ErrBadMsg
 	}
 	n, r, f, s := int32(0), int32(0), int32(0), int32(8)
 	p := s
 	i := int16(0)
-	var usize int32
-	binary.Read(bytes.NewReader(b[0:4]), binary.LittleEndian, &usize)
+	var usize = binary.LittleEndian.Uint32(b[0:4])
+	// every 2 byte back reference expands to at most 257 bytes
+	if usize < 8 || usize > math.MaxInt32 || int64(usize) > 8+int64(len(b))*129 {
+		return nil, ErrBadMsg
+	}
 	dst = make([]byte, usize)
 	d := int32(4)
+	bl := int32(len(b))
 	aa := make([]int32, 256)
 	for int(s) < len(dst) {
 		if i == 0 {
+			if d >= bl {
+				return nil, ErrBadMsg
+			}
 			f = 0xff & int32(b[d])
 			d++
 			i = 1
 		}
 		if (f & int32(i)) != 0 {
+			if d+1 >= bl || int(s)+2 > len(dst) {
+				return nil, ErrBadMsg
+			}
 			r = aa[0xff&int32(b[d])]
 			d++
 			dst[s] = dst[r]
@@ -118,10 +130,16 @@ func Uncompress(b []byte) (dst []byte) {
 			r++
 			n = 0xff & int32(b[d])
 			d++
+			if int(s+n) > len(dst) {
+				return nil, ErrBadMsg
+			}
 			for m := int32(0); m < n; m++ {
 				dst[s+m] = dst[r+m]
 			}
 		} else {
+			if d >= bl {
+				return nil, ErrBadMsg
+			}
 			dst[s] = b[d]
 			s++
 			d++
@@ -139,5 +157,5 @@ func Uncompress(b []byte) (dst []byte) {
 			i = 0
 		}
 	}
-	return dst
+	return dst, nil
 }
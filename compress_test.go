@@ -32,8 +32,14 @@ func TestUncompress(t *testing.T) {
 	}
 	buf := new(bytes.Buffer)
 	_ = Encode(buf, ASYNC, &K{KB, NONE, true2K})
-	uc2 := Uncompress(bytes2KTrue[8:])
-	uc1 := Uncompress(buf.Bytes()[8:])
+	uc2, err := Uncompress(bytes2KTrue[8:])
+	if err != nil {
+		t.Fatal("Uncompress failed:", err)
+	}
+	uc1, err := Uncompress(buf.Bytes()[8:])
+	if err != nil {
+		t.Fatal("Uncompress failed:", err)
+	}
 	if !bytes.Equal(uc1, uc2) {
 		t.Errorf("Uncompress failed expected/got: \n%v\n%v\n", buf.Bytes(), bytes2KTrue)
 	}
@@ -64,6 +70,52 @@ func TestCompressRoundtrip(t *testing.T) {
 	}
 }
 
+func TestUncompressMalformed(t *testing.T) {
+	var malformed = [][]byte{
+		nil,
+		{0x26, 0x00, 0x00},
+		// truncated
+		bytes2KTrue[8:20],
+		// uncompressed size smaller than header
+		{0x04, 0x00, 0x00, 0x00, 0x00, 0x01},
+		// uncompressed size out of proportion to input
+		{0xff, 0xff, 0xff, 0x7f, 0x00, 0x01, 0x02},
+		// back reference running past the end of output
+		{0x0c, 0x00, 0x00, 0x00, 0x01, 0x00, 0xff},
+	}
+	for _, b := range malformed {
+		if _, err := Uncompress(b); err != ErrBadMsg {
+			t.Errorf("Uncompress(%v): expected ErrBadMsg, got %v", b, err)
+		}
+	}
+}
+
+func FuzzUncompress(f *testing.F) {
+	f.Add(bytes2KTrue[8:])
+	for _, tt := range encodingTests {
+		f.Add(Compress(append(tt.expected, tt.expected...))[8:])
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		Uncompress(b)
+		// anything that Compress accepts must survive the roundtrip
+		if len(b) < 8 {
+			return
+		}
+		msg := append([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, b...)
+		c := Compress(msg)
+		if c[2] == 0 {
+			return
+		}
+		uc, err := Uncompress(c[8:])
+		if err != nil {
+			t.Fatalf("Failed to uncompress compressed message: %v", err)
+		}
+		if !bytes.Equal(uc[8:], b) {
+			t.Errorf("Roundtrip failed expected/got: \n%v\n%v\n", b, uc[8:])
+		}
+	})
+}
+
 func BenchmarkUncompress(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		Uncompress(bytes2KTrue[8:])
@@ -0,0 +1,98 @@
+package kdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedGoType is returned by FromGo for values without K representation
+var ErrUnsupportedGoType = errors.New("unsupported Go type")
+
+// FromGo converts Go value to K. Strings map to symbols, time.Time to timestamps,
+// time.Duration to timespans, int to long and slices to vectors of corresponding type.
+// *K is returned as is and nil as generic null.
+func FromGo(v interface{}) (*K, error) {
+	switch x := v.(type) {
+	case nil:
+		return Null(KFUNCUP), nil
+	case *K:
+		if x == nil {
+			return Null(KFUNCUP), nil
+		}
+		return x, nil
+	case bool:
+		return &K{-KB, NONE, x}, nil
+	case []bool:
+		return &K{KB, NONE, x}, nil
+	case GUID:
+		return &K{-UU, NONE, x}, nil
+	case []GUID:
+		return &K{UU, NONE, x}, nil
+	case byte:
+		return &K{-KG, NONE, x}, nil
+	case []byte:
+		return &K{KG, NONE, x}, nil
+	case int16:
+		return &K{-KH, NONE, x}, nil
+	case []int16:
+		return &K{KH, NONE, x}, nil
+	case int32:
+		return Int(x), nil
+	case []int32:
+		return IntV(x), nil
+	case int64:
+		return Long(x), nil
+	case []int64:
+		return LongV(x), nil
+	case int:
+		return Long(int64(x)), nil
+	case []int:
+		l := make([]int64, len(x))
+		for i, e := range x {
+			l[i] = int64(e)
+		}
+		return LongV(l), nil
+	case float32:
+		return Real(x), nil
+	case []float32:
+		return RealV(x), nil
+	case float64:
+		return Float(x), nil
+	case []float64:
+		return FloatV(x), nil
+	case string:
+		return Symbol(x), nil
+	case []string:
+		return SymbolV(x), nil
+	case time.Time:
+		return Timestamp(x), nil
+	case []time.Time:
+		return TimestampV(x), nil
+	case time.Duration:
+		return Timespan(x), nil
+	case []time.Duration:
+		return TimespanV(x), nil
+	case Month:
+		return &K{-KM, NONE, x}, nil
+	case []Month:
+		return &K{KM, NONE, x}, nil
+	case Datetime:
+		return &K{-KZ, NONE, x}, nil
+	case []Datetime:
+		return &K{KZ, NONE, x}, nil
+	case Minute:
+		return &K{-KU, NONE, x}, nil
+	case []Minute:
+		return &K{KU, NONE, x}, nil
+	case Second:
+		return &K{-KV, NONE, x}, nil
+	case []Second:
+		return &K{KV, NONE, x}, nil
+	case Time:
+		return &K{-KT, NONE, x}, nil
+	case []Time:
+		return &K{KT, NONE, x}, nil
+	}
+	return nil, fmt.Errorf("%w %T", ErrUnsupportedGoType, v)
+}
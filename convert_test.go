@@ -0,0 +1,51 @@
+package kdb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFromGo(t *testing.T) {
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var convertTests = []struct {
+		v        interface{}
+		expected *K
+	}{
+		{nil, Null(KFUNCUP)},
+		{Long(1), Long(1)},
+		{true, &K{-KB, NONE, true}},
+		{[]bool{true}, &K{KB, NONE, []bool{true}}},
+		{byte(1), &K{-KG, NONE, byte(1)}},
+		{int16(1), &K{-KH, NONE, int16(1)}},
+		{int32(1), Int(1)},
+		{1, Long(1)},
+		{[]int{1, 2}, LongV([]int64{1, 2})},
+		{float32(1), Real(1)},
+		{1.5, Float(1.5)},
+		{"a", Symbol("a")},
+		{[]string{"a"}, SymbolV([]string{"a"})},
+		{ts, Timestamp(ts)},
+		{time.Second, Timespan(time.Second)},
+		{Month(1), &K{-KM, NONE, Month(1)}},
+		{[]Time{1}, &K{KT, NONE, []Time{1}}},
+		{GUID{1}, &K{-UU, NONE, GUID{1}}},
+	}
+	for _, tt := range convertTests {
+		k, err := FromGo(tt.v)
+		if err != nil {
+			t.Errorf("FromGo(%v): %v", tt.v, err)
+			continue
+		}
+		if !reflect.DeepEqual(k, tt.expected) {
+			t.Errorf("FromGo(%v): expected %v, got %v", tt.v, tt.expected, k)
+		}
+		if err := Validate(k); err != nil {
+			t.Errorf("FromGo(%v): %v", tt.v, err)
+		}
+	}
+	if _, err := FromGo(uint64(1)); !errors.Is(err, ErrUnsupportedGoType) {
+		t.Errorf("expected ErrUnsupportedGoType, got %v", err)
+	}
+}
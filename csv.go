@@ -0,0 +1,222 @@
+package kdb
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOptions control CSV export and import of tables.
+//
+// Temporal values are written as q literals without type suffix, e.g. 2020.01.02D03:04:05.000000000,
+// or with ISO set as ISO 8601 timestamps, dates and months. Nulls are written as empty fields.
+type CSVOptions struct {
+	Delimiter rune // field delimiter, comma if zero
+	NoHeader  bool // no header line with column names
+	ISO       bool // write timestamps, months, dates and datetimes in ISO 8601
+}
+
+// ErrCSVTypes is returned for type string with unknown column types
+var ErrCSVTypes = errors.New("invalid CSV column types")
+
+// csvTypes maps q load type characters, as used by 0:, to vector types
+var csvTypes = map[byte]int8{'B': KB, 'G': UU, 'X': KG, 'H': KH, 'I': KI, 'J': KJ, 'E': KE, 'F': KF, 'C': KC,
+	'S': KS, 'P': KP, 'M': KM, 'D': KD, 'Z': KZ, 'N': KN, 'U': KU, 'V': KV, 'T': KT, '*': K0}
+
+// WriteCSV writes table as CSV with column names in the first line
+func (tbl Table) WriteCSV(w io.Writer, opts CSVOptions) error {
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+	if !opts.NoHeader {
+		if err := cw.Write(tbl.Columns); err != nil {
+			return err
+		}
+	}
+	n := 0
+	if len(tbl.Data) > 0 {
+		n = tbl.Data[0].Len()
+	}
+	row := make([]string, len(tbl.Data))
+	for i := 0; i < n; i++ {
+		for j, c := range tbl.Data {
+			row[j] = csvField(indexK(c, i), opts)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField formats single table cell
+func csvField(k *K, opts CSVOptions) string {
+	switch k.Type {
+	case KC:
+		return k.Data.(string)
+	case -KC:
+		return string([]byte{k.Data.(byte)})
+	case -KS:
+		return k.Data.(string)
+	case -KG:
+		return hex.EncodeToString([]byte{k.Data.(byte)})
+	case -UU:
+		if k.Data.(GUID).IsNull() {
+			return ""
+		}
+		return k.Data.(GUID).String()
+	}
+	if k.Type >= 0 || k.Type < -KT {
+		return FormatLiteral(k)
+	}
+	body, _ := atomBody(k.Type, k.Data)
+	switch {
+	case body == "0N" || body == "0n":
+		return ""
+	case opts.ISO && !nullOrInf(body):
+		if s, ok := isoAtom(k.Type, k.Data); ok {
+			return s
+		}
+	}
+	return body
+}
+
+// ReadCSV reads CSV with header line into table with column types given as in q's 0:,
+// e.g. "SPFJ" for symbol, timestamp, float and long columns. Space skips column, * reads strings.
+func ReadCSV(r io.Reader, types string) (*K, error) {
+	return ReadCSVWithOptions(r, types, CSVOptions{})
+}
+
+// ReadCSVWithOptions reads CSV into table according to types and opts.
+// Without header columns are named x, x1, x2, ... Like q, fields that can't be parsed become nulls.
+func ReadCSVWithOptions(r io.Reader, types string, opts CSVOptions) (*K, error) {
+	for i := 0; i < len(types); i++ {
+		if _, ok := csvTypes[types[i]]; !ok && types[i] != ' ' {
+			return nil, ErrCSVTypes
+		}
+	}
+	cr := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	cr.FieldsPerRecord = len(types)
+	var names []string
+	if opts.NoHeader {
+		names = make([]string, len(types))
+		for i := range names {
+			names[i] = "x"
+			if i > 0 {
+				names[i] += strconv.Itoa(i)
+			}
+		}
+	} else {
+		header, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		names = header
+	}
+	var cols []string
+	var data []*K
+	var index []int
+	for i := 0; i < len(types); i++ {
+		if types[i] == ' ' {
+			continue
+		}
+		t := csvTypes[types[i]]
+		col := &K{t, NONE, emptyCSVColumn(t)}
+		cols, data, index = append(cols, names[i]), append(data, col), append(index, i)
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, col := range data {
+			appendCSVField(col, record[index[j]])
+		}
+	}
+	return NewTable(cols, data), nil
+}
+
+func emptyCSVColumn(t int8) interface{} {
+	switch t {
+	case KC:
+		return ""
+	case K0:
+		return []*K{}
+	}
+	return emptyVector(t)
+}
+
+// appendCSVField parses s and appends it to col
+func appendCSVField(col *K, s string) {
+	switch col.Type {
+	case KC:
+		c := " "
+		if s != "" {
+			c = s[:1]
+		}
+		col.Data = col.Data.(string) + c
+		return
+	case K0:
+		col.Data = append(col.Data.([]*K), &K{KC, NONE, s})
+		return
+	case KS:
+		col.Data = append(col.Data.([]string), s)
+		return
+	}
+	v, err := parseCSVAtom(-col.Type, strings.TrimSpace(s))
+	if err != nil {
+		v = Null(-col.Type).Data
+	}
+	col.Data = appendValue(col.Data, v)
+}
+
+// parseCSVAtom parses field of atom type t as q literal, falling back to ISO 8601 for temporals
+func parseCSVAtom(t int8, s string) (interface{}, error) {
+	switch t {
+	case -KB:
+		switch s {
+		case "1", "t", "T", "y", "Y", "true":
+			return true, nil
+		}
+		return false, nil
+	case -KG:
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil || len(b) != 1 {
+			return nil, &ParseError{0, "bad byte " + s}
+		}
+		return b[0], nil
+	}
+	if s == "" {
+		return Null(t).Data, nil
+	}
+	v, err := parseAtom(t, s)
+	if err == nil {
+		return v, nil
+	}
+	switch t {
+	case -KP:
+		p, err := time.Parse(time.RFC3339Nano, s)
+		return p.UTC(), err
+	case -KM:
+		m, err := time.Parse("2006-01", s)
+		return MonthOf(m), err
+	case -KD:
+		return time.Parse("2006-01-02", s)
+	case -KZ:
+		z, err := time.Parse(time.RFC3339Nano, s)
+		return DatetimeOf(z), err
+	}
+	return nil, err
+}
@@ -0,0 +1,104 @@
+package kdb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCSV(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	tbl := NewTable([]string{"sym", "time", "px", "qty", "note"}, []*K{
+		SymbolV([]string{"a", "b,c"}),
+		TimestampV([]time.Time{ts, Np}),
+		FloatV([]float64{1.5, Nf}),
+		LongV([]int64{Nj, Wj}),
+		NewList(&K{KC, NONE, "say \"hi\""}, &K{KC, NONE, ""}),
+	}).Data.(Table)
+	var csvTests = []struct {
+		opts     CSVOptions
+		expected string
+	}{
+		{CSVOptions{}, "sym,time,px,qty,note\n" +
+			"a,2020.01.02D03:04:05.000000006,1.5,,\"say \"\"hi\"\"\"\n" +
+			"\"b,c\",,,0W,\n"},
+		{CSVOptions{Delimiter: '|', NoHeader: true, ISO: true},
+			"a|2020-01-02T03:04:05.000000006Z|1.5||\"say \"\"hi\"\"\"\n" +
+				"b,c|||0W|\n"},
+	}
+	for _, tt := range csvTests {
+		var buf bytes.Buffer
+		if err := tbl.WriteCSV(&buf, tt.opts); err != nil {
+			t.Errorf("WriteCSV: %v", err)
+			continue
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("WriteCSV: expected %q, got %q", tt.expected, buf.String())
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	in := "sym,time,date,px,qty,skip,flag,c,note\n" +
+		"a,2020.01.02D03:04:05.000000006,2020-01-02,1.5,10,x,1,ab,hello\n" +
+		"b,,2020.01.03,oops,0W,y,0,,\n"
+	k, err := ReadCSV(strings.NewReader(in), "SPDFJ BC*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewTable([]string{"sym", "time", "date", "px", "qty", "flag", "c", "note"}, []*K{
+		SymbolV([]string{"a", "b"}),
+		TimestampV([]time.Time{time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), Np}),
+		DateV([]time.Time{time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)}),
+		FloatV([]float64{1.5, Nf}),
+		LongV([]int64{10, Wj}),
+		&K{KB, NONE, []bool{true, false}},
+		&K{KC, NONE, "a "},
+		NewList(&K{KC, NONE, "hello"}, &K{KC, NONE, ""}),
+	})
+	if FormatLiteral(k) != FormatLiteral(expected) {
+		t.Errorf("expected %s, got %s", FormatLiteral(expected), FormatLiteral(k))
+	}
+	if _, err := ReadCSV(strings.NewReader(in), "SPQ"); err != ErrCSVTypes {
+		t.Errorf("expected ErrCSVTypes, got %v", err)
+	}
+	if _, err := ReadCSV(strings.NewReader(in), "SP"); err == nil {
+		t.Error("expected field count error")
+	}
+}
+
+func TestCSVRoundtrip(t *testing.T) {
+	tbl := NewTable([]string{"b", "g", "x", "h", "i", "e", "c", "m", "z", "n", "u", "v", "t"}, []*K{
+		&K{KB, NONE, []bool{true, false}},
+		&K{UU, NONE, []GUID{{1, 2}, {}}},
+		&K{KG, NONE, []byte{0xab, 0}},
+		&K{KH, NONE, []int16{1, Nh}},
+		IntV([]int32{-Wi, Ni}),
+		&K{KE, NONE, []float32{2.5, -We}},
+		&K{KC, NONE, "xy"},
+		&K{KM, NONE, []Month{241, Nm}},
+		&K{KZ, NONE, []Datetime{0.5, Nz}},
+		TimespanV([]time.Duration{-26 * time.Hour, Nn}),
+		&K{KU, NONE, []Minute{61, Nu}},
+		&K{KV, NONE, []Second{3661, Nv}},
+		&K{KT, NONE, []Time{3661001, Nt}},
+	})
+	for _, opts := range []CSVOptions{{}, {ISO: true, Delimiter: ';'}, {NoHeader: true}} {
+		var buf bytes.Buffer
+		if err := tbl.Data.(Table).WriteCSV(&buf, opts); err != nil {
+			t.Fatal(err)
+		}
+		k, err := ReadCSVWithOptions(&buf, "BGXHIECMZNUVT", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.NoHeader {
+			copy(k.Data.(Table).Columns, tbl.Data.(Table).Columns)
+		}
+		// compare formatted as nulls of floating types are NaN
+		if FormatLiteral(k) != FormatLiteral(tbl) {
+			t.Errorf("%+v: expected %s, got %s", opts, FormatLiteral(tbl), FormatLiteral(k))
+		}
+	}
+}
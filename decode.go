@@ -5,13 +5,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
-	"os"
+	"math"
 	"reflect"
 	"time"
 	"unsafe"
-
-	uuid "github.com/nu7hatch/gouuid"
 )
 
 var typeSize = []int{
@@ -23,7 +22,7 @@ var typeSize = []int{
 
 var typeReflect = []reflect.Type{
 	1:  reflect.TypeOf([]bool{}),
-	2:  reflect.TypeOf([]uuid.UUID{}),
+	2:  reflect.TypeOf([]GUID{}),
 	4:  reflect.TypeOf([]byte{}),
 	5:  reflect.TypeOf([]int16{}),
 	6:  reflect.TypeOf([]int32{}),
@@ -34,39 +33,38 @@ var typeReflect = []reflect.Type{
 	12: reflect.TypeOf([]time.Duration{}),
 	13: reflect.TypeOf([]Month{}),
 	14: reflect.TypeOf([]int32{}),
-	15: reflect.TypeOf([]float64{}),
+	15: reflect.TypeOf([]Datetime{}),
 	16: reflect.TypeOf([]time.Duration{}),
-	17: reflect.TypeOf([]int32{}),
-	18: reflect.TypeOf([]int32{}),
-	19: reflect.TypeOf([]int32{})}
-
-func makeArray(vectype int8, veclen int) interface{} {
-	switch vectype {
-	case 1:
-		return make([]bool, veclen)
-	case 4, 10:
-		return make([]byte, veclen)
-	case 2:
-		return make([]uuid.UUID, veclen)
-	case 5:
-		return make([]int16, veclen)
-	case 6, 14, 17, 18, 19:
-		return make([]int32, veclen)
-	case 13:
-		return make([]Month, veclen)
-	case 16, 12:
-		return make([]time.Duration, veclen)
-	case 7:
-		return make([]int64, veclen)
-	case 8:
-		return make([]float32, veclen)
-	case 9, 15:
-		return make([]float64, veclen)
-	case 11:
-		return make([]string, veclen)
+	17: reflect.TypeOf([]Minute{}),
+	18: reflect.TypeOf([]Second{}),
+	19: reflect.TypeOf([]Time{})}
+
+// maxPrealloc caps memory allocated ahead of reading the data it is meant for,
+// so that forged vector length can't exhaust memory before input runs out
+const maxPrealloc = 1 << 20
+
+// prealloc returns initial capacity for a list of n pointer sized elements
+func prealloc(n uint32) int {
+	if n > maxPrealloc/8 {
+		return maxPrealloc / 8
 	}
+	return int(n)
+}
 
-	return nil
+// readBytes reads exactly n bytes from r, growing the buffer as data arrives
+func readBytes(r io.Reader, n int) ([]byte, error) {
+	if n <= maxPrealloc {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(maxPrealloc)
+	_, err := io.CopyN(&buf, r, int64(n))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
 }
 
 func (h *ipcHeader) getByteOrder() binary.ByteOrder {
@@ -78,12 +76,106 @@ func (h *ipcHeader) getByteOrder() binary.ByteOrder {
 	return order
 }
 
+// DecodeOptions bounds the resources a single message is allowed to consume
+// while being decoded. Zero value of any field means no limit.
+type DecodeOptions struct {
+	MaxMsgSize   uint32 // maximum message size in bytes, after decompression
+	MaxVectorLen uint32 // maximum number of elements in any vector or list
+	MaxDepth     int    // maximum nesting depth of lists, dicts, tables and functions
+	MaxSymbolLen int    // maximum length of symbols, namespaces and error text
+}
+
+// LimitError is returned when decoded message exceeds one of DecodeOptions limits
+type LimitError struct {
+	Err   error // one of ErrMsgTooLarge, ErrVectorTooLong, ErrTooDeep, ErrSymbolTooLong
+	Value int64 // value found in the message
+	Max   int64 // configured limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %d exceeds limit of %d", e.Err, e.Value, e.Max)
+}
+
+// Unwrap returns the sentinel error describing exceeded limit
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+func (opts *DecodeOptions) checkMsgSize(size uint32) error {
+	if opts.MaxMsgSize > 0 && size > opts.MaxMsgSize {
+		return &LimitError{ErrMsgTooLarge, int64(size), int64(opts.MaxMsgSize)}
+	}
+	return nil
+}
+
+// checkVector validates vector length and its payload of veclen elements,
+// each at least elemsize bytes long
+func (opts *DecodeOptions) checkVector(veclen uint32, elemsize int) error {
+	if opts.MaxVectorLen > 0 && veclen > opts.MaxVectorLen {
+		return &LimitError{ErrVectorTooLong, int64(veclen), int64(opts.MaxVectorLen)}
+	}
+	if size := int64(veclen) * int64(elemsize); opts.MaxMsgSize > 0 && size > int64(opts.MaxMsgSize) {
+		return &LimitError{ErrMsgTooLarge, size, int64(opts.MaxMsgSize)}
+	}
+	return nil
+}
+
+// bounded returns copy of opts with MaxMsgSize tightened to size,
+// as no part of the message can be larger than the message itself
+func (opts *DecodeOptions) bounded(size int64) *DecodeOptions {
+	o := *opts
+	if size <= math.MaxUint32 && (o.MaxMsgSize == 0 || uint32(size) < o.MaxMsgSize) {
+		o.MaxMsgSize = uint32(size)
+	}
+	return &o
+}
+
+func (opts *DecodeOptions) checkDepth(depth int) error {
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return &LimitError{ErrTooDeep, int64(depth), int64(opts.MaxDepth)}
+	}
+	return nil
+}
+
+// readString reads 0 terminated string enforcing MaxSymbolLen
+func (opts *DecodeOptions) readString(r *bufio.Reader) (string, error) {
+	var buf []byte
+	for {
+		line, err := r.ReadSlice(0)
+		n := len(buf) + len(line)
+		if err == nil {
+			n--
+		}
+		if opts.MaxSymbolLen > 0 && n > opts.MaxSymbolLen {
+			return "", &LimitError{ErrSymbolTooLong, int64(n), int64(opts.MaxSymbolLen)}
+		}
+		if err == bufio.ErrBufferFull {
+			buf = append(buf, line...)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if buf == nil {
+			return string(line[:len(line)-1]), nil
+		}
+		buf = append(buf, line[:len(line)-1]...)
+		return string(buf), nil
+	}
+}
+
 func (h *ipcHeader) ok() bool {
 	return h.ByteOrder == 0x01 && h.RequestType < 3 && h.Compressed < 0x02 && h.MsgSize > 9
 }
 
 // Decode deserialises data from src in q ipc format.
 func Decode(src *bufio.Reader) (data *K, msgtype ReqType, e error) {
+	return DecodeWithOptions(src, DecodeOptions{})
+}
+
+// DecodeWithOptions deserialises data from src in q ipc format rejecting
+// messages which exceed limits set in opts with *LimitError.
+func DecodeWithOptions(src *bufio.Reader, opts DecodeOptions) (data *K, msgtype ReqType, e error) {
 	var header ipcHeader
 	e = binary.Read(src, binary.LittleEndian, &header)
 	if e != nil {
@@ -92,26 +184,82 @@ func Decode(src *bufio.Reader) (data *K, msgtype ReqType, e error) {
 	if !header.ok() {
 		return nil, -1, errors.New("header is invalid")
 	}
+	if e = opts.checkMsgSize(header.MsgSize); e != nil {
+		return nil, header.RequestType, e
+	}
 	// try to buffer entire message in one go
 	src.Peek(int(header.MsgSize - 8))
 
 	var order = header.getByteOrder()
 	if header.Compressed == 0x01 {
-		compressed := make([]byte, header.MsgSize-8)
-		_, e = io.ReadFull(src, compressed)
+		compressed, e := readBytes(src, int(header.MsgSize-8))
 		if e != nil {
 			return nil, header.RequestType, errors.New("Decode:readcompressed error - " + e.Error())
 		}
-		var uncompressed = Uncompress(compressed)
+		if len(compressed) >= 4 {
+			if e = opts.checkMsgSize(order.Uint32(compressed)); e != nil {
+				return nil, header.RequestType, e
+			}
+		}
+		uncompressed, e := Uncompress(compressed)
+		if e != nil {
+			return nil, header.RequestType, e
+		}
 		var buf = bufio.NewReader(bytes.NewReader(uncompressed[8:]))
-		data, e = readData(buf, order)
+		data, e = readData(buf, order, opts.bounded(int64(len(uncompressed))), 0)
 		return data, header.RequestType, e
 	}
-	data, e = readData(src, order)
+	data, e = readData(src, order, opts.bounded(int64(header.MsgSize)), 0)
 	return data, header.RequestType, e
 }
 
-func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
+// sliceFromBytes returns data as slice of typeReflect[t] sharing its memory
+func sliceFromBytes(t int8, data []byte) interface{} {
+	veclen := len(data) / typeSize[t]
+	head := (*reflect.SliceHeader)(unsafe.Pointer(&data))
+	head.Len = veclen
+	head.Cap = veclen
+	return reflect.Indirect(reflect.NewAt(typeReflect[t], unsafe.Pointer(&data))).Interface()
+}
+
+// vectorFromBytes makes vector of type t in KB..KT sharing memory with little endian data where possible
+func vectorFromBytes(t int8, attr Attr, data []byte) *K {
+	veclen := len(data) / typeSize[t]
+	arr := sliceFromBytes(t, data)
+	switch t {
+	case KC:
+		return &K{t, attr, string(arr.([]byte))}
+	case KP:
+		arr := arr.([]time.Duration)
+		var timearr = make([]time.Time, veclen)
+		for i := 0; i < veclen; i++ {
+			timearr[i] = fromTimestamp(int64(arr[i]))
+		}
+		return &K{t, attr, timearr}
+	case KD:
+		arr := arr.([]int32)
+		var timearr = make([]time.Time, veclen)
+		for i := 0; i < veclen; i++ {
+			timearr[i] = fromDate(arr[i])
+		}
+		return &K{t, attr, timearr}
+	}
+	return &K{t, attr, arr}
+}
+
+// swapBytes reverses byte order of every size bytes long element of data in place
+func swapBytes(data []byte, size int) {
+	for i := 0; i+size <= len(data); i += size {
+		for j, k := i, i+size-1; j < k; j, k = j+1, k-1 {
+			data[j], data[k] = data[k], data[j]
+		}
+	}
+}
+
+func readData(r *bufio.Reader, order binary.ByteOrder, opts *DecodeOptions, depth int) (kobj *K, err error) {
+	if err = opts.checkDepth(depth); err != nil {
+		return nil, err
+	}
 	var msgtype int8
 	err = binary.Read(r, order, &msgtype)
 	if err != nil {
@@ -120,58 +268,109 @@ func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
 	switch msgtype {
 	case -KB:
 		var b byte
-		binary.Read(r, order, &b)
+		if err = binary.Read(r, order, &b); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, b != 0x0}, nil
 
 	case -UU:
-		var u uuid.UUID
-		binary.Read(r, order, &u)
+		var u GUID
+		if err = binary.Read(r, order, &u); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, u}, nil
 
 	case -KG, -KC:
 		var b byte
-		binary.Read(r, order, &b)
+		if err = binary.Read(r, order, &b); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, b}, nil
 	case -KH:
 		var sh int16
-		binary.Read(r, order, &sh)
+		if err = binary.Read(r, order, &sh); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, sh}, nil
 
-	case -KI, -KD, -KU, -KV:
+	case -KI:
 		var i int32
-		binary.Read(r, order, &i)
+		if err = binary.Read(r, order, &i); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, i}, nil
+	case -KD:
+		var d int32
+		if err = binary.Read(r, order, &d); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, fromDate(d)}, nil
+	case -KU:
+		var u Minute
+		if err = binary.Read(r, order, &u); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, u}, nil
+	case -KV:
+		var v Second
+		if err = binary.Read(r, order, &v); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, v}, nil
+	case -KT:
+		var t Time
+		if err = binary.Read(r, order, &t); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, t}, nil
 	case -KJ:
 		var j int64
-		binary.Read(r, order, &j)
+		if err = binary.Read(r, order, &j); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, j}, nil
 	case -KE:
 		var e float32
-		binary.Read(r, order, &e)
+		if err = binary.Read(r, order, &e); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, e}, nil
-	case -KF, -KZ:
+	case -KF:
 		var f float64
-		binary.Read(r, order, &f)
+		if err = binary.Read(r, order, &f); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, f}, nil
+	case -KZ:
+		var z Datetime
+		if err = binary.Read(r, order, &z); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, z}, nil
 	case -KS:
-		line, err := r.ReadBytes(0)
+		str, err := opts.readString(r)
 		if err != nil {
 			return nil, err
 		}
-		str := string(line[:len(line)-1])
 
 		return &K{msgtype, NONE, str}, nil
 	case -KP:
-		var ts time.Duration
-		binary.Read(r, order, &ts)
-		return &K{msgtype, NONE, qEpoch.Add(ts)}, nil
+		var ts int64
+		if err = binary.Read(r, order, &ts); err != nil {
+			return nil, err
+		}
+		return &K{msgtype, NONE, fromTimestamp(ts)}, nil
 	case -KM:
 		var m Month
-		binary.Read(r, order, &m)
+		if err = binary.Read(r, order, &m); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, m}, nil
 	case -KN:
 		var span time.Duration
-		binary.Read(r, order, &span)
+		if err = binary.Read(r, order, &span); err != nil {
+			return nil, err
+		}
 		return &K{msgtype, NONE, span}, nil
 	case KB, UU, KG, KH, KI, KJ, KE, KF, KC, KP, KM, KD, KN, KU, KV, KT, KZ:
 		var vecattr Attr
@@ -179,103 +378,49 @@ func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
 		if err != nil {
 			return nil, errors.New("readData: Failed to read vecattr:" + err.Error())
 		}
+		if vecattr < NONE || vecattr > GROUPED {
+			return nil, ErrBadMsg
+		}
 		var veclen uint32
 		err = binary.Read(r, order, &veclen)
 		if err != nil {
 			return nil, errors.New("Reading vector length failed -> " + err.Error())
 		}
-		var arr interface{}
-		if msgtype >= KB && msgtype <= KT {
-			bytedata := make([]byte, int(veclen)*typeSize[msgtype])
-			_, err = io.ReadFull(r, bytedata)
-			if err != nil {
-				return nil, errors.New("Not enough data - " + err.Error())
-			}
-			head := (*reflect.SliceHeader)(unsafe.Pointer(&bytedata))
-			head.Len = int(veclen)
-			head.Cap = int(veclen)
-			arr = reflect.Indirect(reflect.NewAt(typeReflect[msgtype], unsafe.Pointer(&bytedata))).Interface()
-		} else {
-			arr = makeArray(msgtype, int(veclen))
-			err = binary.Read(r, order, arr)
+		if err = opts.checkVector(veclen, typeSize[msgtype]); err != nil {
+			return nil, err
 		}
+		bytedata, err := readBytes(r, int(veclen)*typeSize[msgtype])
 		if err != nil {
-			return nil, errors.New("Error during conversion -> " + err.Error())
-		}
-		if msgtype == KC {
-			return &K{msgtype, vecattr, string(arr.([]byte))}, nil
-		}
-		if msgtype == KP {
-			arr := arr.([]time.Duration)
-			var timearr = make([]time.Time, veclen)
-			for i := 0; i < int(veclen); i++ {
-				timearr[i] = qEpoch.Add(arr[i])
-			}
-			return &K{msgtype, vecattr, timearr}, nil
-		}
-		if msgtype == KD {
-			arr := arr.([]int32)
-			var timearr = make([]time.Time, veclen)
-			for i := 0; i < int(veclen); i++ {
-				d := time.Duration(arr[i]) * 24 * time.Hour
-				timearr[i] = qEpoch.Add(d)
-			}
-			return &K{msgtype, vecattr, timearr}, nil
-		}
-		if msgtype == KZ {
-			arr := arr.([]float64)
-			var timearr = make([]time.Time, veclen)
-			for i := 0; i < int(veclen); i++ {
-				d := time.Duration(86400000*arr[i]) * time.Millisecond
-				timearr[i] = qEpoch.Add(d)
-			}
-			return &K{msgtype, vecattr, timearr}, nil
-		}
-		if msgtype == KU {
-			arr := arr.([]int32)
-			var timearr = make([]Minute, veclen)
-			for i := 0; i < int(veclen); i++ {
-				d := time.Duration(arr[i]) * time.Minute
-				timearr[i] = Minute(time.Time{}.Add(d))
-			}
-			return &K{msgtype, vecattr, timearr}, nil
-		}
-		if msgtype == KV {
-			arr := arr.([]int32)
-			var timearr = make([]Second, veclen)
-			for i := 0; i < int(veclen); i++ {
-				d := time.Duration(arr[i]) * time.Second
-				timearr[i] = Second(time.Time{}.Add(d))
-			}
-			return &K{msgtype, vecattr, timearr}, nil
+			return nil, errors.New("Not enough data - " + err.Error())
 		}
-		if msgtype == KT {
-			arr := arr.([]int32)
-			var timearr = make([]Time, veclen)
-			for i := 0; i < int(veclen); i++ {
-				timearr[i] = Time(qEpoch.Add(time.Duration(arr[i]) * time.Millisecond))
-			}
-			return &K{msgtype, vecattr, timearr}, nil
+		if order == binary.BigEndian && msgtype != UU {
+			swapBytes(bytedata, typeSize[msgtype])
 		}
-		return &K{msgtype, vecattr, arr}, nil
+		return vectorFromBytes(msgtype, vecattr, bytedata), nil
 	case K0:
 		var vecattr Attr
 		err = binary.Read(r, order, &vecattr)
 		if err != nil {
 			return nil, errors.New("readData: Failed to read vecattr ->" + err.Error())
 		}
+		if vecattr < NONE || vecattr > GROUPED {
+			return nil, ErrBadMsg
+		}
 		var veclen uint32
 		err = binary.Read(r, order, &veclen)
 		if err != nil {
 			return nil, errors.New("Reading vector length failed -> " + err.Error())
 		}
-		var arr = make([]*K, veclen)
+		if err = opts.checkVector(veclen, 1); err != nil {
+			return nil, err
+		}
+		var arr = make([]*K, 0, prealloc(veclen))
 		for i := 0; i < int(veclen); i++ {
-			v, err := readData(r, order)
+			v, err := readData(r, order, opts, depth+1)
 			if err != nil {
 				return nil, err
 			}
-			arr[i] = v
+			arr = append(arr, v)
 		}
 		return &K{msgtype, vecattr, arr}, nil
 	case KS:
@@ -284,41 +429,46 @@ func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
 		if err != nil {
 			return nil, errors.New("readData: Failed to read vecattr ->" + err.Error())
 		}
+		if vecattr < NONE || vecattr > GROUPED {
+			return nil, ErrBadMsg
+		}
 		var veclen uint32
 		err = binary.Read(r, order, &veclen)
 		if err != nil {
 			return nil, errors.New("Reading vector length failed -> " + err.Error())
 		}
-		var arr = makeArray(msgtype, int(veclen)).([]string)
+		if err = opts.checkVector(veclen, 1); err != nil {
+			return nil, err
+		}
+		var arr = make([]string, 0, prealloc(veclen))
 		for i := 0; i < int(veclen); i++ {
-			line, err := r.ReadSlice(0)
+			sym, err := opts.readString(r)
 			if err != nil {
 				return nil, err
 			}
-			arr[i] = string(line[:len(line)-1])
+			arr = append(arr, sym)
 		}
 		return &K{msgtype, vecattr, arr}, nil
 	case XD, SD:
-		dk, err := readData(r, order)
+		dk, err := readData(r, order, opts, depth+1)
 		if err != nil {
 			return nil, err
 		}
-		dv, err := readData(r, order)
+		dv, err := readData(r, order, opts, depth+1)
 		if err != nil {
 			return nil, err
 		}
-		res := NewDict(dk, dv)
-		if msgtype == SD {
-			res.Attr = SORTED
-		}
-		return res, nil
+		return &K{msgtype, NONE, Dict{dk, dv}}, nil
 	case XT:
 		var vecattr Attr
 		err = binary.Read(r, order, &vecattr)
 		if err != nil {
 			return nil, errors.New("readData: Failed to read vecattr" + err.Error())
 		}
-		d, err := readData(r, order)
+		if vecattr < NONE || vecattr > GROUPED {
+			return nil, ErrBadMsg
+		}
+		d, err := readData(r, order, opts, depth+1)
 		if err != nil {
 			return nil, err
 		}
@@ -326,18 +476,23 @@ func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
 			return nil, errors.New("expected dict")
 		}
 		dict := d.Data.(Dict)
+		if dict.Key.Type != KS || dict.Value.Type != K0 {
+			return nil, ErrBadMsg
+		}
 		colNames := dict.Key.Data.([]string)
 		colValues := dict.Value.Data.([]*K)
+		if len(colNames) != len(colValues) {
+			return nil, ErrBadMsg
+		}
 		return &K{msgtype, vecattr, Table{colNames, colValues}}, nil
 
 	case KFUNC:
 		var f Function
-		line, err := r.ReadSlice(0)
+		f.Namespace, err = opts.readString(r)
 		if err != nil {
 			return nil, err
 		}
-		f.Namespace = string(line[:len(line)-1])
-		b, err := readData(r, order)
+		b, err := readData(r, order, opts, depth+1)
 		if err != nil {
 			return nil, err
 		}
@@ -359,52 +514,100 @@ func readData(r *bufio.Reader, order binary.ByteOrder) (kobj *K, err error) {
 		if err != nil {
 			return nil, err
 		}
-		var res = make([]*K, n)
-		for i := 0; i < len(res); i++ {
-			res[i], err = readData(r, order)
+		if err = opts.checkVector(n, 1); err != nil {
+			return nil, err
+		}
+		var res = make([]*K, 0, prealloc(n))
+		for i := 0; i < int(n); i++ {
+			v, err := readData(r, order, opts, depth+1)
 			if err != nil {
 				return nil, err
 			}
+			res = append(res, v)
 		}
 		return &K{msgtype, NONE, res}, nil
 	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
-		res, err := readData(r, order)
+		res, err := readData(r, order, opts, depth+1)
 		if err != nil {
 			return nil, err
 		}
 		return &K{msgtype, NONE, res}, nil
 	case KDYNLOAD:
 		// 112 - dynamic load
-		return nil, errors.New("type is unsupported")
+		return nil, &UnsupportedTypeError{msgtype}
 	case KERR:
-		line, err := r.ReadSlice(0)
+		errmsg, err := opts.readString(r)
 		if err != nil {
 			return nil, err
 		}
-		errmsg := string(line[:len(line)-1])
 		return nil, errors.New(errmsg)
 	}
+	if isEnum(msgtype) || isEnum(-msgtype) {
+		return readEnum(r, order, msgtype, opts)
+	}
+	if isUnsupported(msgtype) {
+		return nil, &UnsupportedTypeError{msgtype}
+	}
 	return nil, ErrBadMsg
 }
 
+// Deserialize decodes message produced by q's -8! or -18!, same as q's -9!.
+// Big endian messages are accepted and converted, trailing bytes after the object are an error.
+func Deserialize(b []byte) (*K, error) {
+	var header ipcHeader
+	if len(b) < 8 {
+		return nil, ErrBadHeader
+	}
+	binary.Read(bytes.NewReader(b[:8]), binary.LittleEndian, &header)
+	order := header.getByteOrder()
+	if header.ByteOrder > 0x01 || header.RequestType > RESPONSE || header.Compressed > 0x01 ||
+		int64(order.Uint32(b[4:8])) != int64(len(b)) {
+		return nil, ErrBadHeader
+	}
+	body := b[8:]
+	if header.Compressed == 0x01 {
+		uncompressed, err := Uncompress(body)
+		if err != nil {
+			return nil, err
+		}
+		body = uncompressed[8:]
+	}
+	var opts DecodeOptions
+	r := bufio.NewReader(bytes.NewReader(body))
+	k, err := readData(r, order, opts.bounded(int64(len(body))), 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return nil, ErrBadMsg
+	}
+	return k, nil
+}
+
 func ReadFromBuffer(data *bytes.Buffer) (*K, error) {
 	var order = binary.LittleEndian
+	var opts DecodeOptions
+	size := data.Len()
 	reader := bufio.NewReader(data)
-	// Read 0xFF, 0x01 bytes magic number
-	reader.ReadByte()
-	reader.ReadByte()
-	return readData(reader, order)
+	if err := readFilePrefix(reader); err != nil {
+		return nil, err
+	}
+	return readData(reader, order, opts.bounded(int64(size)), 0)
 }
 
+// ReadFromFile reads object from file written by WriteToFile, or vector mapped by q
 func ReadFromFile(filename string) (*K, error) {
-	var order = binary.LittleEndian
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+	return readFileObject(filename)
+}
+
+// readFilePrefix reads 0xFF, 0x01 bytes magic number written by WriteToBuffer and WriteToFile
+func readFilePrefix(r *bufio.Reader) error {
+	var prefix [2]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return err
 	}
-	reader := bufio.NewReader(f)
-	// Read 0xFF, 0x01 bytes magic number
-	reader.ReadByte()
-	reader.ReadByte()
-	return readData(reader, order)
+	if prefix != [2]byte{0xFF, 0x01} {
+		return ErrBadHeader
+	}
+	return nil
 }
@@ -3,7 +3,10 @@ package kdb
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -176,3 +179,267 @@ func BenchmarkDecodeAll(b *testing.B) {
 		}
 	}
 }
+
+func TestDecodeLimits(t *testing.T) {
+	var limitTests = []struct {
+		desc string
+		msg  []byte
+		opts DecodeOptions
+		err  error
+	}{
+		{"message size", TableBytes, DecodeOptions{MaxMsgSize: 16}, ErrMsgTooLarge},
+		{"vector length", ByteVectorBytes, DecodeOptions{MaxVectorLen: 4}, ErrVectorTooLong},
+		{"symbol vector length", SymbolVectorBytes, DecodeOptions{MaxVectorLen: 2}, ErrVectorTooLong},
+		{"vector payload", []byte{0x01, 0x00, 0x00, 0x00, 0x12, 0x00, 0x00, 0x00, 0x07, 0x00, 0xff, 0xff, 0xff, 0x7f, 0x00, 0x00, 0x00, 0x00}, DecodeOptions{MaxMsgSize: 1024}, ErrMsgTooLarge},
+		{"table depth", TableBytes, DecodeOptions{MaxDepth: 2}, ErrTooDeep},
+		{"keyed table depth", KeyedTableBytes, DecodeOptions{MaxDepth: 3}, ErrTooDeep},
+		{"symbol length", SymbolBytes, DecodeOptions{MaxSymbolLen: 3}, ErrSymbolTooLong},
+		{"symbol vector symbol length", SymbolVectorBytes, DecodeOptions{MaxSymbolLen: 2}, ErrSymbolTooLong},
+		{"error text length", ErrorBytes, DecodeOptions{MaxSymbolLen: 2}, ErrSymbolTooLong},
+		{"within limits", KeyedTableBytes, DecodeOptions{MaxMsgSize: 1024, MaxVectorLen: 1, MaxDepth: 4, MaxSymbolLen: 1}, nil},
+	}
+	for _, tt := range limitTests {
+		_, _, err := DecodeWithOptions(bufio.NewReader(bytes.NewReader(tt.msg)), tt.opts)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Decoding '%s' with %+v: expected error %v, got %v", tt.desc, tt.opts, tt.err, err)
+		}
+		if tt.err != nil {
+			var lerr *LimitError
+			if !errors.As(err, &lerr) {
+				t.Errorf("Decoding '%s': expected *LimitError, got %T", tt.desc, err)
+			}
+		}
+	}
+}
+
+func TestConnDecodeOptions(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &KDBConn{con: client, rbuf: bufio.NewReader(client)}
+	c.SetDecodeOptions(DecodeOptions{MaxVectorLen: 4})
+	go server.Write(ByteVectorBytes)
+	if _, _, err := c.ReadMessage(); !errors.Is(err, ErrVectorTooLong) {
+		t.Errorf("expected %v, got %v", ErrVectorTooLong, err)
+	}
+	if _, err := client.Write([]byte{0}); err == nil {
+		t.Error("expected connection to be closed after message over limits")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			HandleClientConnectionWithOptions(conn, DecodeOptions{MaxVectorLen: 4})
+		}
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("user\x03\x00"))
+	conn.Read(make([]byte, 1))
+	msg := append([]byte{}, ByteVectorBytes...)
+	msg[1] = byte(SYNC)
+	conn.Write(msg)
+	res, _, err := Decode(bufio.NewReader(conn))
+	if err == nil || !strings.Contains(err.Error(), ErrVectorTooLong.Error()) {
+		t.Errorf("expected %v reply, got %v %v", ErrVectorTooLong, res, err)
+	}
+}
+
+func TestDecodeLongSymbol(t *testing.T) {
+	// symbol longer than bufio buffer has to be read in chunks
+	sym := strings.Repeat("a", 5000)
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, ASYNC, Symbol(sym)); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReaderSize(bytes.NewReader(buf.Bytes()), 16)
+	d, _, err := DecodeWithOptions(r, DecodeOptions{MaxSymbolLen: 5000})
+	if err != nil || d.Data.(string) != sym {
+		t.Errorf("Failed to decode long symbol: %v", err)
+	}
+	r = bufio.NewReaderSize(bytes.NewReader(buf.Bytes()), 16)
+	_, _, err = DecodeWithOptions(r, DecodeOptions{MaxSymbolLen: 4999})
+	if !errors.Is(err, ErrSymbolTooLong) {
+		t.Errorf("Expected ErrSymbolTooLong, got %v", err)
+	}
+}
+
+// checkLimits verifies that decoded k stays within opts
+func checkLimits(k *K, opts DecodeOptions, depth int) bool {
+	if k == nil {
+		return true
+	}
+	if depth > opts.MaxDepth {
+		return false
+	}
+	switch d := k.Data.(type) {
+	case string:
+		return len(d) <= opts.MaxSymbolLen || k.Type == KC
+	case []string:
+		for _, s := range d {
+			if len(s) > opts.MaxSymbolLen {
+				return false
+			}
+		}
+		return uint32(len(d)) <= opts.MaxVectorLen
+	case []*K:
+		if uint32(len(d)) > opts.MaxVectorLen {
+			return false
+		}
+		for _, v := range d {
+			if !checkLimits(v, opts, depth+1) {
+				return false
+			}
+		}
+	case Dict:
+		return checkLimits(d.Key, opts, depth+1) && checkLimits(d.Value, opts, depth+1)
+	case Table:
+		for _, v := range d.Data {
+			if !checkLimits(v, opts, depth+2) {
+				return false
+			}
+		}
+	case *K:
+		return checkLimits(d, opts, depth+1)
+	}
+	if k.Type > K0 && k.Type <= KT && k.Type != KC {
+		return uint32(k.Len()) <= opts.MaxVectorLen
+	}
+	return true
+}
+
+func FuzzDecodeWithOptions(f *testing.F) {
+	for _, tt := range encodingTests {
+		f.Add(tt.expected)
+	}
+	opts := DecodeOptions{MaxMsgSize: 1 << 16, MaxVectorLen: 64, MaxDepth: 4, MaxSymbolLen: 8}
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		d, _, err := DecodeWithOptions(bufio.NewReader(bytes.NewReader(msg)), opts)
+		if err != nil {
+			return
+		}
+		if !checkLimits(d, opts, 0) {
+			t.Errorf("Decoded object %v exceeds limits %+v", d, opts)
+		}
+	})
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	var malformed = []struct {
+		desc string
+		msg  []byte
+	}{
+		{"truncated int", IntBytes[:10]},
+		{"truncated vector", ByteVectorBytes[:16]},
+		{"bad attribute", []byte{0x01, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x00, 0x00, 0x04, 0x07, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{"table with non-symbol keys", []byte{0x01, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x00, 0x00, 0x62, 0x00, 0x63,
+			0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"table over non-dict", []byte{0x01, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x00, 0x00, 0x62, 0x00, 0xfa, 0x01, 0x00, 0x00, 0x00}},
+		{"forged message size", []byte("\x01\x00\x00\x00\x0e\xc5\xc5\xc5\x00\x00\x00\x80type\x00")},
+		{"huge list", []byte{0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}},
+		{"corrupt compression", append([]byte{0x01, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x00, 0xff, 0x00, 0x00, 0x00}, 0x00, 0xff, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06)},
+	}
+	for _, tt := range malformed {
+		_, _, err := Decode(bufio.NewReader(bytes.NewReader(tt.msg)))
+		if err == nil {
+			t.Errorf("Decoding malformed '%s' succeeded", tt.desc)
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, tt := range encodingTests {
+		f.Add(tt.expected)
+	}
+	f.Add(bytes2KTrue)
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		Decode(bufio.NewReader(bytes.NewReader(msg)))
+	})
+}
+
+func FuzzReadFromBuffer(f *testing.F) {
+	for _, tt := range encodingTests {
+		f.Add(append([]byte{0xff, 0x01}, tt.expected[8:]...))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ReadFromBuffer(bytes.NewBuffer(b))
+	})
+}
+
+func TestDeserialize(t *testing.T) {
+	for _, tt := range encodingTests {
+		d, err := Deserialize(tt.expected)
+		if tt.input.Type == KERR {
+			d = Error(err)
+		} else if err != nil {
+			t.Errorf("Deserialize '%s' failed: %v", tt.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, tt.input) {
+			t.Errorf("Deserialized '%s' incorrectly. Expected '%#v', got '%#v'", tt.desc, tt.input, d)
+		}
+	}
+	d, err := Deserialize(bytes2KTrue)
+	if err != nil || d.Type != KB || d.Len() != 2000 {
+		t.Errorf("Deserialize compressed: got %v %v", d, err)
+	}
+	// -8!1 on big endian machine
+	bigEndian := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x11, 0xf9, 0, 0, 0, 0, 0, 0, 0, 0x01}
+	if d, err := Deserialize(bigEndian); err != nil || !reflect.DeepEqual(d, Long(1)) {
+		t.Errorf("Deserialize big endian: got %v %v", d, err)
+	}
+	bigEndianVectors := []struct {
+		b        []byte
+		expected *K
+	}{
+		// -8!1 2
+		{[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1e, 0x07, 0x00, 0x00, 0x00, 0x00, 0x02,
+			0, 0, 0, 0, 0, 0, 0, 0x01, 0, 0, 0, 0, 0, 0, 0, 0x02}, LongV([]int64{1, 2})},
+		// -8!1 -2h
+		{[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x02,
+			0x00, 0x01, 0xff, 0xfe}, &K{KH, NONE, []int16{1, -2}}},
+		// -8!enlist 2000.01.02
+		{[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x0e, 0x00, 0x00, 0x00, 0x00, 0x01,
+			0x00, 0x00, 0x00, 0x01}, DateV([]time.Time{qEpoch.AddDate(0, 0, 1)})},
+		// -8!"ab"
+		{[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x02,
+			'a', 'b'}, &K{KC, NONE, "ab"}},
+	}
+	for _, tt := range bigEndianVectors {
+		if d, err := Deserialize(tt.b); err != nil || !reflect.DeepEqual(d, tt.expected) {
+			t.Errorf("Deserialize big endian: expected %v, got %v %v", tt.expected, d, err)
+		}
+	}
+	for _, b := range [][]byte{
+		nil,
+		IntBytes[:len(IntBytes)-1],
+		append(append([]byte{}, IntBytes...), 0),
+		{0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x00, 0x00, 0xfa, 0x01, 0x00, 0x00, 0x00, 0x00},
+		{0x02, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00, 0xfa, 0x01, 0x00, 0x00, 0x00},
+		{0xff, 0x01, 0xfa, 0x01, 0x00, 0x00, 0x00},
+	} {
+		if _, err := Deserialize(b); err == nil {
+			t.Errorf("Deserialize(%v): expected error", b)
+		}
+	}
+}
+
+func TestReadFromBuffer(t *testing.T) {
+	buf, err := WriteToBuffer(Int(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, err := ReadFromBuffer(buf); err != nil || !reflect.DeepEqual(d, Int(1)) {
+		t.Errorf("ReadFromBuffer: got %v %v", d, err)
+	}
+	if _, err := ReadFromBuffer(bytes.NewBuffer(IntBytes)); err != ErrBadHeader {
+		t.Errorf("expected ErrBadHeader, got %v", err)
+	}
+}
@@ -12,7 +12,12 @@ import (
 )
 
 func writeData(dbuf io.Writer, order binary.ByteOrder, data *K) (err error) {
-	binary.Write(dbuf, order, data.Type)
+	if data.Type == XD && data.Attr == SORTED {
+		// dict with sorted attribute is sent as sorted dict
+		binary.Write(dbuf, order, SD)
+	} else {
+		binary.Write(dbuf, order, data.Type)
+	}
 	if data.Type >= K0 && data.Type < XD {
 		binary.Write(dbuf, order, data.Attr) // attributes
 
@@ -51,17 +56,30 @@ func writeData(dbuf io.Writer, order binary.ByteOrder, data *K) (err error) {
 			val = 0x00
 		}
 		binary.Write(dbuf, order, val)
-	case -KG, -KH, -KI, -KJ, -KE, -KF, -UU:
+	case -KG, -KC, -KH, -KI, -KJ, -KE, -KF, -UU:
+		binary.Write(dbuf, order, data.Data)
+	case -KM, -KZ, -KN, -KU, -KV, -KT:
 		binary.Write(dbuf, order, data.Data)
 	case -KP:
 		tosend := data.Data.(time.Time)
-		binary.Write(dbuf, order, tosend.Sub(qEpoch))
+		binary.Write(dbuf, order, toTimestamp(tosend))
 	case KP:
 		binary.Write(dbuf, order, int32(reflect.ValueOf(data.Data).Len()))
 		tosend := data.Data.([]time.Time)
 		for _, ts := range tosend {
-			binary.Write(dbuf, order, ts.Sub(qEpoch))
+			binary.Write(dbuf, order, toTimestamp(ts))
+		}
+	case -KD:
+		tosend := data.Data.(time.Time)
+		binary.Write(dbuf, order, toDate(tosend))
+	case KD:
+		tosend := data.Data.([]time.Time)
+		days := make([]int32, len(tosend))
+		for i, d := range tosend {
+			days[i] = toDate(d)
 		}
+		binary.Write(dbuf, order, int32(len(days)))
+		binary.Write(dbuf, order, days)
 	case KB:
 		binary.Write(dbuf, order, int32(reflect.ValueOf(data.Data).Len()))
 		tosend := data.Data.([]bool)
@@ -69,10 +87,10 @@ func writeData(dbuf io.Writer, order binary.ByteOrder, data *K) (err error) {
 		for _, b := range tosend {
 			binary.Write(dbuf, order, boolmap[b])
 		}
-	case KG, KI, KJ, KE, KF, KZ, KT, KD, KV, KU, KM, KN, UU:
+	case KG, KH, KI, KJ, KE, KF, KZ, KT, KV, KU, KM, KN, UU:
 		binary.Write(dbuf, order, int32(reflect.ValueOf(data.Data).Len()))
 		binary.Write(dbuf, order, data.Data)
-	case XD:
+	case XD, SD:
 		tosend := data.Data.(Dict)
 		err = writeData(dbuf, order, tosend.Key)
 		if err != nil {
@@ -121,26 +139,73 @@ func writeData(dbuf io.Writer, order binary.ByteOrder, data *K) (err error) {
 			return err
 		}
 	default:
+		if isEnum(data.Type) || isEnum(-data.Type) {
+			return writeEnum(dbuf, order, data)
+		}
+		if isUnsupported(data.Type) {
+			return &UnsupportedTypeError{data.Type}
+		}
 		return errors.New("unknown type " + strconv.Itoa(int(data.Type)))
 	}
 	return nil
 
 }
 
+// EncodeOptions controls optional steps performed by EncodeWithOptions
+type EncodeOptions struct {
+	Validate bool // run Validate on data before encoding
+}
+
 // Encode data to ipc format as msgtype(sync/async/response) to specified writer
 func Encode(w io.Writer, msgtype ReqType, data *K) error {
+	return EncodeWithOptions(w, msgtype, data, EncodeOptions{})
+}
+
+// EncodeWithOptions encodes data same as Encode, but fails without writing anything
+// if opts.Validate is set and data doesn't pass Validate
+func EncodeWithOptions(w io.Writer, msgtype ReqType, data *K, opts EncodeOptions) error {
+	if opts.Validate {
+		if err := Validate(data); err != nil {
+			return err
+		}
+	}
+	b, err := serialize(msgtype, data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(Compress(b))
+	return err
+}
+
+// Serialize returns data in the same format as q's -8!, i.e. message with ipc header
+func Serialize(data *K) ([]byte, error) {
+	return serialize(ASYNC, data)
+}
+
+// SerializeCompressed returns data in the same format as q's -18!,
+// compressed unless it is too small or doesn't compress
+func SerializeCompressed(data *K) ([]byte, error) {
+	b, err := serialize(ASYNC, data)
+	if err != nil {
+		return nil, err
+	}
+	return Compress(b), nil
+}
+
+// serialize encodes data as uncompressed ipc message of msgtype
+func serialize(msgtype ReqType, data *K) ([]byte, error) {
 	var order = binary.LittleEndian
 	buf := new(bytes.Buffer)
 
 	// As a place holder header, write 8 bytes to the buffer
 	header := [8]byte{}
 	if _, err := buf.Write(header[:]); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Then write the qipc encoded data
 	if err := writeData(buf, order, data); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Now that we have the length of the buffer, create the correct header
@@ -153,9 +218,7 @@ func Encode(w io.Writer, msgtype ReqType, data *K) error {
 	// Write the correct header to the original buffer
 	b := buf.Bytes()
 	copy(b, header[:])
-
-	_, err := w.Write(Compress(b))
-	return err
+	return b, nil
 }
 
 func WriteToBuffer(data *K) (*bytes.Buffer, error) {
@@ -1,11 +1,12 @@
 package kdb
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"time"
 	//"fmt"
-	"github.com/nu7hatch/gouuid"
 	"testing"
 )
 
@@ -37,18 +38,19 @@ var encodingTests = []struct {
 	{"`a`b!enlist each 2 3", NewDict(SymbolV([]string{"a", "b"}),
 		NewList([]*K{IntV([]int32{2}), IntV([]int32{3})}...)),
 		DictWithVectorsBytes},
-	{"1#2013.06.10T22:03:49.713", &K{KZ, NONE, []time.Time{DatetimeAsTime}}, DateTimeVecBytes},
+	{"1#2013.06.10T22:03:49.713", &K{KZ, NONE, []Datetime{DatetimeOf(DatetimeAsTime)}}, DateTimeVecBytes},
 	{"1#2013.06.10", &K{KD, NONE, []time.Time{DateAsTime}}, DateVecBytes},
-	{"1#21:53:37.963", &K{KT, NONE, []int32{78817963}}, TimeVecBytes},
-	{"21:22:01 + 1 2", &K{KV, NONE, []int32{76922, 76923}}, SecondVecBytes},
-	{"21:22*til 2", &K{KU, NONE, []int32{0, 1282}}, MinuteVecBytes},
+	{"1#21:53:37.963", &K{KT, NONE, []Time{78817963}}, TimeVecBytes},
+	{"21:22:01 + 1 2", &K{KV, NONE, []Second{76922, 76923}}, SecondVecBytes},
+	{"21:22*til 2", &K{KU, NONE, []Minute{0, 1282}}, MinuteVecBytes},
 	{"2013.06m +til 3", &K{KM, NONE, []Month{161, 162, 163}}, MonthVecBytes},
 	{"2018.01.26D01:49:00.884361000", &K{-KP, NONE, TimestampAsTime}, TimestampAsBytes},
 	{"2#2018.01.26D01:49:00.884361000", &K{KP, NONE, []time.Time{TimestampAsTime, TimestampAsTime}}, TimestampVectorAsBytes},
-	{"8c6b8b64-6815-6084-0a3e-178401251b68", &K{-UU, NONE, uuid.UUID{0x8c, 0x6b, 0x8b, 0x64, 0x68, 0x15, 0x60, 0x84, 0x0a, 0x3e, 0x17, 0x84, 0x01, 0x25, 0x1b, 0x68}}, GUIDBytes},
-	{"0x0 sv/: 16 cut `byte$til 32", &K{UU, NONE, []uuid.UUID{{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}, {0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f}}}, GUIDVecBytes},
+	{"8c6b8b64-6815-6084-0a3e-178401251b68", &K{-UU, NONE, GUID{0x8c, 0x6b, 0x8b, 0x64, 0x68, 0x15, 0x60, 0x84, 0x0a, 0x3e, 0x17, 0x84, 0x01, 0x25, 0x1b, 0x68}}, GUIDBytes},
+	{"0x0 sv/: 16 cut `byte$til 32", &K{UU, NONE, []GUID{{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}, {0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f}}}, GUIDVecBytes},
 	{"0D01:22:33.444555666*1+til 2", &K{KN, NONE, []time.Duration{4953444555666, 9906889111332}}, TimespanVecBytes},
-	{"`s#`a`b!2 3", &K{XD, SORTED, Dict{&K{KS, SORTED, []string{"a", "b"}}, IntV([]int32{2, 3})}}, SortedDictBytes},
+	{"`s#`a`b!2 3", &K{SD, NONE, Dict{&K{KS, SORTED, []string{"a", "b"}}, IntV([]int32{2, 3})}}, SortedDictBytes},
+	{"`s#([a:enlist 2]b:enlist 3)", &K{SD, NONE, Dict{NewTable([]string{"a"}, []*K{IntV([]int32{2})}), NewTable([]string{"b"}, []*K{IntV([]int32{3})})}}, SortedKeyedTableByes},
 	{"`s#([]a:enlist 2;b:enlist 3)", &K{XT, SORTED, Table{[]string{"a", "b"}, []*K{{KI, PARTED, []int32{2}}, IntV([]int32{3})}}}, SortedTableBytes},
 	{"-8!sums", &K{KSCAN, NONE, &K{KFUNCBP, NONE, byte(1)}}, []byte{0x01, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x00, 0x00, 0x6c, 0x66, 0x01}},
 	{"-8!2000.01.01", Date(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)), []byte{0x01, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00, 0xf2, 0x00, 0x00, 0x00, 0x00}},
@@ -86,3 +88,102 @@ func BenchmarkEncodeAll(b *testing.B) {
 		}
 	}
 }
+
+// ipcMsg wraps body in ipc header of async message
+func ipcMsg(body []byte) []byte {
+	msg := append([]byte{0x01, 0x00, 0x00, 0x00, 0, 0, 0, 0}, body...)
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(msg)))
+	return msg
+}
+
+// elements returns value, null and infinity bit patterns for elements of vector type t
+func elements(t int8) [][]byte {
+	switch t {
+	case KB:
+		return [][]byte{{1}, {0}}
+	case KC:
+		return [][]byte{{'a'}, {' '}}
+	case KS:
+		return [][]byte{[]byte("ab\x00"), {0}}
+	}
+	value := make([]byte, typeSize[t])
+	null := make([]byte, typeSize[t])
+	inf := make([]byte, typeSize[t])
+	for i := range value {
+		value[i], inf[i] = byte(i+1), 0xff
+	}
+	if t != KG && t != UU {
+		null[len(null)-1], inf[len(inf)-1] = 0x80, 0x7f
+	}
+	return [][]byte{value, null, inf}
+}
+
+func TestEncodeDecodeIdentity(t *testing.T) {
+	var msgs [][]byte
+	for typ := KB; typ <= KT; typ++ {
+		if typ == 3 {
+			continue
+		}
+		elems := elements(typ)
+		for _, e := range elems {
+			msgs = append(msgs, ipcMsg(append([]byte{byte(-typ)}, e...)))
+		}
+		for attr := NONE; attr <= GROUPED; attr++ {
+			vec := []byte{byte(typ), byte(attr), byte(len(elems)), 0, 0, 0}
+			for _, e := range elems {
+				vec = append(vec, e...)
+			}
+			msgs = append(msgs, ipcMsg(vec))
+			empty := []byte{byte(typ), byte(attr), 0, 0, 0, 0}
+			msgs = append(msgs, ipcMsg(empty))
+			// the same vector as list item, dict value and table column
+			msgs = append(msgs, ipcMsg(append([]byte{0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0xf9, 1, 0, 0, 0, 0, 0, 0, 0}, vec...)))
+			msgs = append(msgs, ipcMsg(append([]byte{0x63, 0xf5, 0x61, 0x00}, vec...)))
+			msgs = append(msgs, ipcMsg(append([]byte{0x62, byte(attr), 0x63, 0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x61, 0x00,
+				0x00, 0x00, 0x01, 0x00, 0x00, 0x00}, vec...)))
+		}
+	}
+	for _, tt := range encodingTests {
+		if tt.input.Type != KERR {
+			msgs = append(msgs, tt.expected)
+		}
+	}
+	for _, msg := range msgs {
+		k, _, err := Decode(bufio.NewReader(bytes.NewReader(msg)))
+		if err != nil {
+			t.Errorf("Decode(%x): %v", msg, err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := Encode(buf, ASYNC, k); err != nil {
+			t.Errorf("Encode(%v) from %x: %v", k, msg, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), msg) {
+			t.Errorf("Encode(Decode(%x)) = %x", msg, buf.Bytes())
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	for _, tt := range encodingTests {
+		b, err := Serialize(tt.input)
+		if err != nil {
+			t.Errorf("Serialize '%s' failed: %v", tt.desc, err)
+			continue
+		}
+		if !bytes.Equal(b, tt.expected) {
+			t.Errorf("Serialized '%s' incorrectly. Expected '%v', got '%v'", tt.desc, tt.expected, b)
+		}
+	}
+	true2K := make([]bool, 2000)
+	for i := range true2K {
+		true2K[i] = true
+	}
+	if b, err := SerializeCompressed(&K{KB, NONE, true2K}); err != nil || !bytes.Equal(b, bytes2KTrue) {
+		t.Errorf("SerializeCompressed: expected %v, got %v %v", bytes2KTrue, b, err)
+	}
+	if b, err := SerializeCompressed(Int(1)); err != nil || !bytes.Equal(b, IntBytes) {
+		t.Errorf("SerializeCompressed: expected %v, got %v %v", IntBytes, b, err)
+	}
+}
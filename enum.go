@@ -0,0 +1,153 @@
+package kdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Enum is enumeration of symbols against domain, e.g. `sym$`a`b
+//
+// On the wire enumeration of type t in 20..76 is sent as type, attribute and
+// vector length followed by 0 terminated domain name and int64 indices.
+// Atom of type -t carries domain name and single index.
+type Enum struct {
+	Domain string  // name of the domain variable, e.g. sym
+	Index  []int64 // positions in the domain, null long for values not in domain
+}
+
+// String returns enumeration formatted as `dom!i j k
+func (e Enum) String() string {
+	var b strings.Builder
+	b.WriteString("`" + e.Domain + "!")
+	for i, x := range e.Index {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if x == Nj {
+			b.WriteString("0N")
+		} else {
+			b.WriteString(strconv.FormatInt(x, 10))
+		}
+	}
+	return b.String()
+}
+
+// UnsupportedTypeError is returned for q types that can't be represented in IPC messages
+type UnsupportedTypeError struct {
+	Type int8
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	var name string
+	switch t := e.Type; {
+	case t == KDYNLOAD:
+		name = "foreign"
+	case t == KANYMAP:
+		name = "anymap"
+	case t == KNESTSYM:
+		name = "nested sym enum"
+	case t > KANYMAP && t < KNESTSYM:
+		name = "mapped list"
+	default:
+		name = "unknown"
+	}
+	return "type " + strconv.Itoa(int(e.Type)) + " (" + name + ") is unsupported"
+}
+
+// ErrNotEnum is returned when enum operation is applied to non-enumerated value
+var ErrNotEnum = errors.New("not an enumeration")
+
+// Enumeration creates enumerated vector of type t over domain
+func Enumeration(t int8, domain string, index []int64) *K {
+	return &K{t, NONE, Enum{domain, index}}
+}
+
+// Resolve maps enumerated atom or vector to symbols of its domain.
+// Indices outside of domain resolve to null symbol.
+func (k *K) Resolve(symbols []string) (*K, error) {
+	var t int8
+	if isEnum(k.Type) {
+		t = KS
+	} else if isEnum(-k.Type) {
+		t = -KS
+	} else {
+		return nil, ErrNotEnum
+	}
+	e, ok := k.Data.(Enum)
+	if !ok {
+		return nil, ErrNotEnum
+	}
+	res := make([]string, len(e.Index))
+	for i, x := range e.Index {
+		if x >= 0 && x < int64(len(symbols)) {
+			res[i] = symbols[x]
+		}
+	}
+	if t == -KS {
+		if len(res) != 1 {
+			return nil, ErrNotEnum
+		}
+		return Symbol(res[0]), nil
+	}
+	return &K{KS, k.Attr, res}, nil
+}
+
+func isEnum(t int8) bool {
+	return t >= KE0 && t <= KEMAX
+}
+
+func isUnsupported(t int8) bool {
+	return t == KDYNLOAD || (t >= KANYMAP && t <= KNESTSYM)
+}
+
+func readEnum(r *bufio.Reader, order binary.ByteOrder, msgtype int8, opts *DecodeOptions) (*K, error) {
+	var vecattr Attr
+	var veclen uint32 = 1
+	if msgtype > 0 {
+		if err := binary.Read(r, order, &vecattr); err != nil {
+			return nil, err
+		}
+		if vecattr < NONE || vecattr > GROUPED {
+			return nil, ErrBadMsg
+		}
+		if err := binary.Read(r, order, &veclen); err != nil {
+			return nil, err
+		}
+		if err := opts.checkVector(veclen, 8); err != nil {
+			return nil, err
+		}
+	}
+	domain, err := opts.readString(r)
+	if err != nil {
+		return nil, err
+	}
+	bytedata, err := readBytes(r, int(veclen)*8)
+	if err != nil {
+		return nil, err
+	}
+	index := make([]int64, veclen)
+	if err = binary.Read(bytes.NewReader(bytedata), order, index); err != nil {
+		return nil, err
+	}
+	return &K{msgtype, vecattr, Enum{domain, index}}, nil
+}
+
+func writeEnum(dbuf io.Writer, order binary.ByteOrder, data *K) error {
+	e, ok := data.Data.(Enum)
+	if !ok {
+		return ErrNotEnum
+	}
+	if data.Type > 0 {
+		binary.Write(dbuf, order, int32(len(e.Index)))
+	} else if len(e.Index) != 1 {
+		return ErrNotEnum
+	}
+	binary.Write(dbuf, order, []byte(e.Domain))
+	binary.Write(dbuf, order, byte(0))
+	return binary.Write(dbuf, order, e.Index)
+}
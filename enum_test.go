@@ -0,0 +1,100 @@
+package kdb
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var enumVector = Enumeration(20, "sym", []int64{0, 1, Nj})
+
+// `sym$`a`b`, followed by null enum
+var enumVectorBytes = []byte{0x01, 0x00, 0x00, 0x00, 0x2a, 0x00, 0x00, 0x00,
+	0x14, 0x00, 0x03, 0x00, 0x00, 0x00, 0x73, 0x79, 0x6d, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80}
+
+func TestEnumRoundTrip(t *testing.T) {
+	var enumTests = []*K{
+		enumVector,
+		&K{21, SORTED, Enum{"dom", []int64{1, 2, 3}}},
+		&K{-20, NONE, Enum{"sym", []int64{7}}},
+		Enumeration(KEMAX, "x", []int64{}),
+	}
+	for _, k := range enumTests {
+		buf := new(bytes.Buffer)
+		if err := EncodeWithOptions(buf, ASYNC, k, EncodeOptions{Validate: true}); err != nil {
+			t.Errorf("Encode(%v): %v", k, err)
+			continue
+		}
+		d, _, err := Decode(bufio.NewReader(buf))
+		if err != nil {
+			t.Errorf("Decode(%v): %v", k, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, k) {
+			t.Errorf("expected %#v, got %#v", k, d)
+		}
+	}
+}
+
+func TestEnumEncoding(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, ASYNC, enumVector); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), enumVectorBytes) {
+		t.Errorf("expected %v, got %v", enumVectorBytes, buf.Bytes())
+	}
+}
+
+func TestEnumResolve(t *testing.T) {
+	domain := []string{"a", "b"}
+	var resolveTests = []struct {
+		k        *K
+		expected *K
+		err      error
+	}{
+		{enumVector, SymbolV([]string{"a", "b", ""}), nil},
+		{&K{20, SORTED, Enum{"sym", []int64{0, 1}}}, &K{KS, SORTED, []string{"a", "b"}}, nil},
+		{&K{-20, NONE, Enum{"sym", []int64{1}}}, Symbol("b"), nil},
+		{&K{-20, NONE, Enum{"sym", []int64{5}}}, Symbol(""), nil},
+		{SymbolV([]string{"a"}), nil, ErrNotEnum},
+	}
+	for _, tt := range resolveTests {
+		r, err := tt.k.Resolve(domain)
+		if err != tt.err {
+			t.Errorf("Resolve(%v): expected error %v, got %v", tt.k, tt.err, err)
+		}
+		if !reflect.DeepEqual(r, tt.expected) {
+			t.Errorf("Resolve(%v): expected %v, got %v", tt.k, tt.expected, r)
+		}
+	}
+}
+
+func TestEnumString(t *testing.T) {
+	if s := enumVector.String(); s != "`sym!0 1 0N" {
+		t.Errorf("unexpected string %q", s)
+	}
+	if n := enumVector.Len(); n != 3 {
+		t.Errorf("expected length 3, got %d", n)
+	}
+}
+
+func TestUnsupportedTypes(t *testing.T) {
+	for _, typ := range []int8{KANYMAP, KMAPPED, 90, KNESTSYM, KDYNLOAD} {
+		msg := []byte{0x01, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, byte(typ), 0x00}
+		_, _, err := Decode(bufio.NewReader(bytes.NewReader(msg)))
+		var uerr *UnsupportedTypeError
+		if !errors.As(err, &uerr) || uerr.Type != typ {
+			t.Errorf("decoding type %d: expected UnsupportedTypeError, got %v", typ, err)
+		}
+		err = Encode(new(bytes.Buffer), ASYNC, &K{typ, NONE, nil})
+		if !errors.As(err, &uerr) || uerr.Type != typ {
+			t.Errorf("encoding type %d: expected UnsupportedTypeError, got %v", typ, err)
+		}
+	}
+}
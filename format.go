@@ -0,0 +1,371 @@
+package kdb
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// q names of vector types, used to format empty vectors
+var typeNames = []string{KB: "boolean", UU: "guid", KG: "byte", KH: "short", KI: "int", KJ: "long",
+	KE: "real", KF: "float", KC: "char", KS: "symbol", KP: "timestamp", KM: "month", KD: "date",
+	KZ: "datetime", KN: "timespan", KU: "minute", KV: "second", KT: "time"}
+
+// FormatLiteral formats k as q literal the same way q console displays it.
+// Data literals and lambdas can be read back with ParseLiteral.
+func FormatLiteral(k *K) string {
+	var b strings.Builder
+	writeQ(&b, k)
+	return b.String()
+}
+
+func writeQ(b *strings.Builder, k *K) {
+	if k == nil {
+		b.WriteString("::")
+		return
+	}
+	if k.Type < K0 && k.Type >= -KT {
+		b.WriteString(formatAtom(k.Type, k.Data))
+		return
+	}
+	if k.Type > K0 && k.Type <= KT {
+		b.WriteString(attrPrint[k.Attr])
+		writeVector(b, k)
+		return
+	}
+	if isEnum(k.Type) || isEnum(-k.Type) {
+		b.WriteString(attrPrint[k.Attr] + k.Data.(Enum).String())
+		return
+	}
+	switch k.Type {
+	case K0:
+		b.WriteString(attrPrint[k.Attr])
+		list := k.Data.([]*K)
+		if len(list) == 1 {
+			b.WriteString(",")
+			writeQ(b, list[0])
+			return
+		}
+		writeList(b, "(", list, ")")
+	case XD, SD:
+		if k.Type == SD {
+			b.WriteString(attrPrint[SORTED])
+		} else {
+			b.WriteString(attrPrint[k.Attr])
+		}
+		d := k.Data.(Dict)
+		if d.Key.Type == XT && d.Value.Type == XT {
+			b.WriteString("([")
+			writeColumns(b, d.Key.Data.(Table))
+			b.WriteString("] ")
+			writeColumns(b, d.Value.Data.(Table))
+			b.WriteString(")")
+			return
+		}
+		if d.Key.Type > K0 && d.Key.Type <= KT && d.Key.Attr == NONE && d.Key.Len() > 1 {
+			writeQ(b, d.Key)
+		} else {
+			b.WriteString("(")
+			writeQ(b, d.Key)
+			b.WriteString(")")
+		}
+		b.WriteString("!")
+		writeQ(b, d.Value)
+	case XT:
+		b.WriteString(attrPrint[k.Attr])
+		b.WriteString("([] ")
+		writeColumns(b, k.Data.(Table))
+		b.WriteString(")")
+	case KFUNC:
+		b.WriteString(k.Data.(Function).Body)
+	case KFUNCUP:
+		if op := k.Data.(byte); op != elidedArg {
+			b.WriteString(unaryops[op])
+		}
+	case KFUNCBP:
+		b.WriteString(binaryops[k.Data.(byte)])
+	case KFUNCTR:
+		b.WriteString(ternaryops[k.Data.(byte)])
+	case KPROJ:
+		list := k.Data.([]*K)
+		if len(list) == 0 {
+			return
+		}
+		writeQ(b, list[0])
+		writeList(b, "[", list[1:], "]")
+	case KCOMP:
+		writeList(b, "'[", k.Data.([]*K), "]")
+	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
+		writeQ(b, k.Data.(*K))
+		b.WriteString(adverbs[k.Type])
+	case KERR:
+		b.WriteString("'" + k.Data.(error).Error())
+	default:
+		b.WriteString("unknown")
+	}
+}
+
+func writeList(b *strings.Builder, open string, list []*K, close string) {
+	b.WriteString(open)
+	for i, l := range list {
+		if i > 0 {
+			b.WriteString(";")
+		}
+		writeQ(b, l)
+	}
+	b.WriteString(close)
+}
+
+func writeColumns(b *strings.Builder, t Table) {
+	for i, c := range t.Columns {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(c + ":")
+		writeQ(b, t.Data[i])
+	}
+}
+
+func writeVector(b *strings.Builder, k *K) {
+	n := k.Len()
+	switch {
+	case k.Type == KC:
+		if n == 1 {
+			b.WriteString(",")
+		}
+		b.WriteString(quoteQ(k.Data.(string)))
+		return
+	case n == 0:
+		b.WriteString("`" + typeNames[k.Type] + "$()")
+		return
+	case n == 1:
+		b.WriteString(",")
+		b.WriteString(formatAtom(-k.Type, k.Index(0)))
+		return
+	case k.Type == KS:
+		syms := k.Data.([]string)
+		for _, s := range syms {
+			if !plainSymbol(s) {
+				b.WriteString("`$(")
+				for i, s := range syms {
+					if i > 0 {
+						b.WriteString(";")
+					}
+					b.WriteString(quoteQ(s))
+				}
+				b.WriteString(")")
+				return
+			}
+		}
+		for _, s := range syms {
+			b.WriteString("`" + s)
+		}
+		return
+	case k.Type == KG:
+		b.WriteString("0x" + hex.EncodeToString(k.Data.([]byte)))
+		return
+	}
+	sep := " "
+	if k.Type == KB {
+		sep = ""
+	}
+	var suffix string
+	typed := false
+	for i := 0; i < n; i++ {
+		body, sfx := atomBody(-k.Type, k.Index(i))
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(body)
+		suffix = sfx
+		typed = typed || selfTyped(k.Type, body)
+	}
+	if !typed {
+		b.WriteString(suffix)
+	}
+}
+
+// formatAtom formats atom of type t with value v as q literal
+func formatAtom(t int8, v interface{}) string {
+	switch t {
+	case -KC:
+		return quoteQ(string([]byte{v.(byte)}))
+	case -KS:
+		s := v.(string)
+		if plainSymbol(s) {
+			return "`" + s
+		}
+		return "`$" + quoteQ(s)
+	case -KG:
+		return "0x" + hex.EncodeToString([]byte{v.(byte)})
+	}
+	body, suffix := atomBody(t, v)
+	if selfTyped(-t, body) {
+		return body
+	}
+	return body + suffix
+}
+
+// selfTyped reports whether body of vector element of type t is enough to infer the type
+func selfTyped(t int8, body string) bool {
+	switch t {
+	case KF:
+		return strings.ContainsAny(body, ".enw")
+	case KJ, UU:
+		return true
+	case KP, KD, KZ, KN, KU, KV, KT:
+		return !nullOrInf(body)
+	}
+	return false
+}
+
+func nullOrInf(body string) bool {
+	return body == "0N" || body == "0W" || body == "-0W"
+}
+
+// atomBody returns element formatted without type suffix and the suffix itself
+func atomBody(t int8, v interface{}) (string, string) {
+	switch t {
+	case -KB:
+		if v.(bool) {
+			return "1", "b"
+		}
+		return "0", "b"
+	case -UU:
+		return v.(GUID).String(), ""
+	case -KH:
+		return intBody(int64(v.(int16)), int64(Nh), int64(Wh)), "h"
+	case -KI:
+		return intBody(int64(v.(int32)), int64(Ni), int64(Wi)), "i"
+	case -KJ:
+		return intBody(v.(int64), Nj, Wj), ""
+	case -KE:
+		f := float64(v.(float32))
+		if s := floatSpecial(f, "0N", "0W"); s != "" {
+			return s, "e"
+		}
+		return floatBody(f, 32), "e"
+	case -KF:
+		f := v.(float64)
+		if s := floatSpecial(f, "0n", "0w"); s != "" {
+			return s, "f"
+		}
+		return floatBody(f, 64), "f"
+	case -KP:
+		ns := toTimestamp(v.(time.Time))
+		if s := intBody(ns, Nj, Wj); nullOrInf(s) {
+			return s, "p"
+		}
+		return fromTimestamp(ns).Format("2006.01.02D15:04:05.000000000"), "p"
+	case -KM:
+		m := v.(Month)
+		if s := intBody(int64(m), int64(Ni), int64(Wi)); nullOrInf(s) {
+			return s, "m"
+		}
+		return strings.TrimSuffix(m.String(), "m"), "m"
+	case -KD:
+		d := toDate(v.(time.Time))
+		if s := intBody(int64(d), int64(Ni), int64(Wi)); nullOrInf(s) {
+			return s, "d"
+		}
+		return fromDate(d).Format("2006.01.02"), "d"
+	case -KZ:
+		z := float64(v.(Datetime))
+		if s := floatSpecial(z, "0N", "0W"); s != "" {
+			return s, "z"
+		}
+		return v.(Datetime).String(), "z"
+	case -KN:
+		d := v.(time.Duration)
+		if s := intBody(int64(d), Nj, Wj); nullOrInf(s) {
+			return s, "n"
+		}
+		sign, ns := signAbs(int64(d))
+		day := int64(24 * time.Hour)
+		rest := time.Duration(ns % day)
+		return sign + strconv.FormatInt(ns/day, 10) + "D" +
+			time.Unix(0, 0).UTC().Add(rest).Format("15:04:05.000000000"), "n"
+	case -KU:
+		return temporalBody(int32(v.(Minute)), v.(Minute).String()), "u"
+	case -KV:
+		return temporalBody(int32(v.(Second)), v.(Second).String()), "v"
+	case -KT:
+		return temporalBody(int32(v.(Time)), v.(Time).String()), "t"
+	}
+	return "", ""
+}
+
+func intBody(v, null, inf int64) string {
+	switch v {
+	case null:
+		return "0N"
+	case inf:
+		return "0W"
+	case -inf:
+		return "-0W"
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func temporalBody(v int32, s string) string {
+	if b := intBody(int64(v), int64(Ni), int64(Wi)); nullOrInf(b) {
+		return b
+	}
+	return s
+}
+
+func floatSpecial(f float64, null, inf string) string {
+	switch {
+	case math.IsNaN(f):
+		return null
+	case math.IsInf(f, 1):
+		return inf
+	case math.IsInf(f, -1):
+		return "-" + inf
+	}
+	return ""
+}
+
+func floatBody(f float64, bits int) string {
+	return strings.Replace(strconv.FormatFloat(f, 'g', -1, bits), "e+", "e", 1)
+}
+
+// plainSymbol reports whether s can be written as `s
+func plainSymbol(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.:/", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteQ quotes s as q string literal
+func quoteQ(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString("\\n")
+		case '\r':
+			b.WriteString("\\r")
+		case '\t':
+			b.WriteString("\\t")
+		default:
+			if c < 0x20 || c == 0x7f {
+				b.WriteString("\\" + strconv.FormatInt(int64(c)|0x200, 8)[1:])
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package kdb
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+var formatTests = []struct {
+	k        *K
+	expected string
+}{
+	{&K{-KB, NONE, true}, "1b"},
+	{&K{KB, NONE, []bool{true, false, true}}, "101b"},
+	{&K{-KG, NONE, byte(10)}, "0x0a"},
+	{&K{KG, NONE, []byte{1, 255}}, "0x01ff"},
+	{&K{-KH, NONE, int16(3)}, "3h"},
+	{&K{KH, NONE, []int16{1, Nh, -Wh}}, "1 0N -0Wh"},
+	{Int(Ni), "0Ni"},
+	{IntV([]int32{1, 2}), "1 2i"},
+	{Long(5), "5"},
+	{LongV([]int64{1, Nj, Wj}), "1 0N 0W"},
+	{LongV([]int64{}), "`long$()"},
+	{LongV([]int64{7}), ",7"},
+	{&K{-KE, NONE, float32(1.5)}, "1.5e"},
+	{&K{-KE, NONE, Ne}, "0Ne"},
+	{Float(2), "2f"},
+	{Float(2.5), "2.5"},
+	{Float(1e20), "1e20"},
+	{Float(math.NaN()), "0n"},
+	{Float(math.Inf(-1)), "-0w"},
+	{FloatV([]float64{1, 2}), "1 2f"},
+	{FloatV([]float64{1, 2.5, Nf}), "1 2.5 0n"},
+	{&K{-KC, NONE, byte('a')}, "\"a\""},
+	{&K{KC, NONE, "a\"b\n"}, "\"a\\\"b\\n\""},
+	{&K{KC, NONE, "x"}, ",\"x\""},
+	{Symbol("abc"), "`abc"},
+	{Symbol(""), "`"},
+	{Symbol("a b"), "`$\"a b\""},
+	{SymbolV([]string{"a", "b"}), "`a`b"},
+	{&K{KS, SORTED, []string{"a", "b"}}, "`s#`a`b"},
+	{SymbolV([]string{"a", "b c"}), "`$(\"a\";\"b c\")"},
+	{Timestamp(time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)), "2020.01.02D03:04:05.000000006"},
+	{Timestamp(Np), "0Np"},
+	{TimestampV([]time.Time{Np, Np}), "0N 0Np"},
+	{&K{-KM, NONE, Month(161)}, "2013.06m"},
+	{&K{KM, NONE, []Month{0, Nm}}, "2000.01 0Nm"},
+	{Date(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), "2020.01.01"},
+	{DateV([]time.Time{Nd, Wd}), "0N 0Wd"},
+	{&K{-KZ, NONE, Nz}, "0Nz"},
+	{Timespan(-(25*time.Hour + 1)), "-1D01:00:00.000000001"},
+	{Timespan(Nn), "0Nn"},
+	{&K{-KU, NONE, Minute(61)}, "01:01"},
+	{&K{KV, NONE, []Second{1, Nv}}, "00:00:01 0N"},
+	{&K{-KT, NONE, Nt}, "0Nt"},
+	{NewList(Long(1), Symbol("a")), "(1;`a)"},
+	{NewList(), "()"},
+	{NewDict(SymbolV([]string{"a", "b"}), LongV([]int64{1, 2})), "`a`b!1 2"},
+	{NewDict(SymbolV([]string{"a"}), LongV([]int64{1})), "(,`a)!,1"},
+	{NewTable([]string{"a", "b"}, []*K{LongV([]int64{1, 2}), SymbolV([]string{"x", "y"})}), "([] a:1 2; b:`x`y)"},
+	{&K{KERR, NONE, errors.New("type")}, "'type"},
+}
+
+func TestFormatLiteral(t *testing.T) {
+	for _, tt := range formatTests {
+		if s := FormatLiteral(tt.k); s != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, s)
+		}
+	}
+}
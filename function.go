@@ -0,0 +1,91 @@
+package kdb
+
+import "errors"
+
+// ErrUnknownOp is returned by NewPrimitive for operators q doesn't have
+var ErrUnknownOp = errors.New("unknown operator")
+
+// elided argument marker, displayed as empty argument in projections like f[;1]
+const elidedArg byte = 255
+
+// Elided returns placeholder for missing argument of projection
+func Elided() *K {
+	return &K{KFUNCUP, NONE, elidedArg}
+}
+
+// IsElided reports whether k is missing argument of projection
+func (k *K) IsElided() bool {
+	return k.Type == KFUNCUP && k.Data == elidedArg
+}
+
+// NewPrimitive creates q primitive (e.g. +, til, /) from its q name.
+// Binary form takes precedence, unary forms are written with colon, e.g. -:.
+func NewPrimitive(op string) (*K, error) {
+	if i := indexOf(binaryops, op); i >= 0 {
+		return &K{KFUNCBP, NONE, byte(i)}, nil
+	}
+	if i := indexOf(unaryops, op); i >= 0 && op != "" {
+		return &K{KFUNCUP, NONE, byte(i)}, nil
+	}
+	if i := indexOf(ternaryops, op); i >= 0 {
+		return &K{KFUNCTR, NONE, byte(i)}, nil
+	}
+	return nil, ErrUnknownOp
+}
+
+// NewProjection creates projection f[args], nil arguments are elided
+func NewProjection(f *K, args ...*K) *K {
+	list := make([]*K, 0, len(args)+1)
+	list = append(list, f)
+	for _, a := range args {
+		if a == nil {
+			a = Elided()
+		}
+		list = append(list, a)
+	}
+	return &K{KPROJ, NONE, list}
+}
+
+// NewComposition creates composition '[f;g;...] applying the last function first
+func NewComposition(fs ...*K) *K {
+	return &K{KCOMP, NONE, fs}
+}
+
+// Each creates f'
+func Each(f *K) *K {
+	return &K{KEACH, NONE, f}
+}
+
+// Over creates f/
+func Over(f *K) *K {
+	return &K{KOVER, NONE, f}
+}
+
+// Scan creates f\
+func Scan(f *K) *K {
+	return &K{KSCAN, NONE, f}
+}
+
+// Prior creates f':
+func Prior(f *K) *K {
+	return &K{KPRIOR, NONE, f}
+}
+
+// EachRight creates f/:
+func EachRight(f *K) *K {
+	return &K{KEACHRIGHT, NONE, f}
+}
+
+// EachLeft creates f\:
+func EachLeft(f *K) *K {
+	return &K{KEACHLEFT, NONE, f}
+}
+
+func indexOf(ops []string, op string) int {
+	for i, o := range ops {
+		if o == op {
+			return i
+		}
+	}
+	return -1
+}
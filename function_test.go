@@ -0,0 +1,78 @@
+package kdb
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func mustPrimitive(t *testing.T, op string) *K {
+	f, err := NewPrimitive(op)
+	if err != nil {
+		t.Fatalf("NewPrimitive(%q): %v", op, err)
+	}
+	return f
+}
+
+func TestFunctionBuilders(t *testing.T) {
+	plus := mustPrimitive(t, "+")
+	lambda := NewFunc("", "{x+y}")
+	var builderTests = []struct {
+		k        *K
+		expected string
+	}{
+		{lambda, "{x+y}"},
+		{NewFunc("d", "{x*2}"), "{x*2}"},
+		{plus, "+"},
+		{mustPrimitive(t, "avg"), "avg"},
+		{mustPrimitive(t, "-:"), "-:"},
+		{mustPrimitive(t, "::"), "::"},
+		{mustPrimitive(t, "/"), "/"},
+		{NewProjection(lambda, nil, Long(1)), "{x+y}[;1]"},
+		{NewProjection(plus, Long(1)), "+[1]"},
+		{NewProjection(NewFunc("", "{x+y+z}"), Symbol("a"), nil, nil), "{x+y+z}[`a;;]"},
+		{NewProjection(lambda, Null(KFUNCUP), Float(2)), "{x+y}[::;2f]"},
+		{NewComposition(mustPrimitive(t, "-:"), plus), "'[-:;+]"},
+		{Over(plus), "+/"},
+		{Scan(lambda), "{x+y}\\"},
+		{Each(NewProjection(plus, Long(1))), "+[1]'"},
+		{Prior(mustPrimitive(t, "-")), "-':"},
+		{EachRight(mustPrimitive(t, ",")), ",/:"},
+		{EachLeft(mustPrimitive(t, ",")), ",\\:"},
+	}
+	for _, tt := range builderTests {
+		if s := tt.k.String(); s != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, s)
+		}
+		buf := new(bytes.Buffer)
+		if err := EncodeWithOptions(buf, ASYNC, tt.k, EncodeOptions{Validate: true}); err != nil {
+			t.Errorf("Encode(%s): %v", tt.expected, err)
+			continue
+		}
+		d, _, err := Decode(bufio.NewReader(buf))
+		if err != nil {
+			t.Errorf("Decode(%s): %v", tt.expected, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, tt.k) {
+			t.Errorf("roundtrip of %s: expected %#v, got %#v", tt.expected, tt.k, d)
+		}
+	}
+}
+
+func TestElided(t *testing.T) {
+	p := NewProjection(NewFunc("", "{x+y}"), nil, Long(1))
+	args := p.Data.([]*K)
+	if !args[1].IsElided() || args[2].IsElided() || Null(KFUNCUP).IsElided() {
+		t.Errorf("unexpected elided arguments in %v", p)
+	}
+}
+
+func TestUnknownPrimitive(t *testing.T) {
+	for _, op := range []string{"", "foo", "++"} {
+		if _, err := NewPrimitive(op); err != ErrUnknownOp {
+			t.Errorf("NewPrimitive(%q): expected ErrUnknownOp, got %v", op, err)
+		}
+	}
+}
@@ -1,5 +1,3 @@
 module github.com/sv/kdbgo
 
 go 1.18
-
-require github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d
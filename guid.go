@@ -0,0 +1,125 @@
+package kdb
+
+import (
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"reflect"
+)
+
+// GUID represents guid type in kdb
+type GUID [16]byte
+
+// ErrBadGUID to indicate malformed guid text or binary representation
+var ErrBadGUID = errors.New("invalid guid")
+
+// ParseGUID parses guid in canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+// as well as 32 hex digits without dashes
+func ParseGUID(s string) (GUID, error) {
+	var g GUID
+	err := g.UnmarshalText([]byte(s))
+	return g, err
+}
+
+// GUIDFromBytes converts 16 byte slice to GUID
+func GUIDFromBytes(b []byte) (GUID, error) {
+	var g GUID
+	err := g.UnmarshalBinary(b)
+	return g, err
+}
+
+// GUIDFrom converts value of any uuid library implementing encoding.BinaryMarshaler,
+// e.g. github.com/google/uuid.UUID or github.com/gofrs/uuid.UUID, to GUID
+func GUIDFrom(u encoding.BinaryMarshaler) (GUID, error) {
+	b, err := u.MarshalBinary()
+	if err != nil {
+		return GUID{}, err
+	}
+	return GUIDFromBytes(b)
+}
+
+// AssignTo stores g into value of any uuid library implementing encoding.BinaryUnmarshaler
+func (g GUID) AssignTo(u encoding.BinaryUnmarshaler) error {
+	return u.UnmarshalBinary(g[:])
+}
+
+// IsNull reports whether g is null guid 0Ng
+func (g GUID) IsNull() bool {
+	return g == GUID{}
+}
+
+func (g GUID) String() string {
+	b, _ := g.MarshalText()
+	return string(b)
+}
+
+// MarshalText formats g in canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (g GUID) MarshalText() ([]byte, error) {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], g[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], g[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], g[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], g[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], g[10:])
+	return b, nil
+}
+
+// UnmarshalText parses g from either canonical form or 32 hex digits
+func (g *GUID) UnmarshalText(text []byte) error {
+	var digits []byte
+	switch len(text) {
+	case 32:
+		digits = text
+	case 36:
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return ErrBadGUID
+		}
+		digits = make([]byte, 0, 32)
+		digits = append(digits, text[0:8]...)
+		digits = append(digits, text[9:13]...)
+		digits = append(digits, text[14:18]...)
+		digits = append(digits, text[19:23]...)
+		digits = append(digits, text[24:]...)
+	default:
+		return ErrBadGUID
+	}
+	if _, err := hex.Decode(g[:], digits); err != nil {
+		return ErrBadGUID
+	}
+	return nil
+}
+
+// MarshalBinary returns 16 bytes of g
+func (g GUID) MarshalBinary() ([]byte, error) {
+	return g[:], nil
+}
+
+// UnmarshalBinary sets g from 16 byte slice
+func (g *GUID) UnmarshalBinary(b []byte) error {
+	if len(b) != len(g) {
+		return ErrBadGUID
+	}
+	copy(g[:], b)
+	return nil
+}
+
+// assignGUID stores g into fv if it is a 16 byte array (as most uuid types are),
+// a string or implements encoding.BinaryUnmarshaler
+func assignGUID(fv reflect.Value, g GUID) bool {
+	ft := fv.Type()
+	switch {
+	case ft.Kind() == reflect.Array && ft.Len() == len(g) && ft.Elem().Kind() == reflect.Uint8:
+		fv.Set(reflect.ValueOf(g).Convert(ft))
+	case ft.Kind() == reflect.String:
+		fv.SetString(g.String())
+	case fv.CanAddr() && fv.Addr().Type().Implements(reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()):
+		return fv.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(g[:]) == nil
+	default:
+		return false
+	}
+	return true
+}
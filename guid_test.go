@@ -0,0 +1,85 @@
+package kdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var testGUID = GUID{0x8c, 0x6b, 0x8b, 0x64, 0x68, 0x15, 0x60, 0x84, 0x0a, 0x3e, 0x17, 0x84, 0x01, 0x25, 0x1b, 0x68}
+
+func TestParseGUID(t *testing.T) {
+	for _, s := range []string{"8c6b8b64-6815-6084-0a3e-178401251b68", "8C6B8B64-6815-6084-0A3E-178401251B68", "8c6b8b64681560840a3e178401251b68"} {
+		g, err := ParseGUID(s)
+		if err != nil || g != testGUID {
+			t.Errorf("ParseGUID(%s) = %v, %v", s, g, err)
+		}
+	}
+	for _, s := range []string{"", "8c6b8b64-6815-6084-0a3e-178401251b6", "8c6b8b64+6815-6084-0a3e-178401251b68", "8c6b8b64-6815-6084-0a3e-178401251bxx"} {
+		if _, err := ParseGUID(s); err != ErrBadGUID {
+			t.Errorf("ParseGUID(%s): expected ErrBadGUID, got %v", s, err)
+		}
+	}
+	if testGUID.String() != "8c6b8b64-6815-6084-0a3e-178401251b68" {
+		t.Errorf("Unexpected string %s", testGUID)
+	}
+	if !(GUID{}).IsNull() || testGUID.IsNull() {
+		t.Error("IsNull failed")
+	}
+}
+
+// otherUUID mimics uuid types from third party libraries
+type otherUUID [16]byte
+
+func (u otherUUID) MarshalBinary() ([]byte, error) {
+	return u[:], nil
+}
+
+func (u *otherUUID) UnmarshalBinary(b []byte) error {
+	if len(b) != 16 {
+		return errors.New("bad length")
+	}
+	copy(u[:], b)
+	return nil
+}
+
+// wrappedUUID mimics uuid types which are not plain arrays
+type wrappedUUID struct {
+	b []byte
+}
+
+func (u *wrappedUUID) UnmarshalBinary(b []byte) error {
+	u.b = append([]byte(nil), b...)
+	return nil
+}
+
+func TestGUIDConversion(t *testing.T) {
+	g, err := GUIDFrom(otherUUID(testGUID))
+	if err != nil || g != testGUID {
+		t.Errorf("GUIDFrom failed: %v, %v", g, err)
+	}
+	var u otherUUID
+	if err = testGUID.AssignTo(&u); err != nil || u != otherUUID(testGUID) {
+		t.Errorf("AssignTo failed: %v, %v", u, err)
+	}
+	if _, err = GUIDFromBytes([]byte{1, 2}); err != ErrBadGUID {
+		t.Errorf("Expected ErrBadGUID, got %v", err)
+	}
+}
+
+func TestUnmarshalDictGUID(t *testing.T) {
+	var v struct {
+		A GUID
+		B otherUUID
+		C string
+		D wrappedUUID
+	}
+	guids := []*K{{-UU, NONE, testGUID}, {-UU, NONE, testGUID}, {-UU, NONE, testGUID}, {-UU, NONE, testGUID}}
+	d := Dict{SymbolV([]string{"a", "b", "c", "d"}), NewList(guids...)}
+	if err := UnmarshalDict(d, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != testGUID || v.B != otherUUID(testGUID) || v.C != testGUID.String() || !bytes.Equal(v.D.b, testGUID[:]) {
+		t.Errorf("Unexpected result %+v", v)
+	}
+}
@@ -0,0 +1,905 @@
+package kdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// JSONOptions controls JSON encoding of K objects.
+//
+// By default atoms map to JSON scalars, vectors and lists to arrays, dicts with symbol keys to objects
+// and tables to arrays of row objects. Nulls are written as null, infinities as "Infinity" and "-Infinity".
+// Temporal values are ISO 8601 strings: timestamps and datetimes in UTC with Z suffix, dates as 2006-01-02,
+// months as 2006-01, times of day as 15:04:05.000 and timespans as durations like P1DT2H3M4.000000005S.
+// Functions, enumerations and other types without JSON counterpart are written as q literals.
+//
+// Typed mode writes every object as {"t":type,"a":attr,"v":value} with value in its q representation,
+// e.g. days since 2000.01.01 for dates, and is decoded back losslessly by UnmarshalJSONWithOptions with
+// Typed set, never by UnmarshalJSON as ordinary objects may look the same. Its strings hold
+// one character per byte, bytes 0x80 and above are written as \u0080..\u00ff rather than as UTF-8.
+type JSONOptions struct {
+	Columns        bool // write tables as objects of column arrays
+	Typed          bool // write q type codes for lossless round trip
+	InfinityAsNull bool // write infinities as null
+}
+
+// ErrBadJSON is returned for JSON that can't be converted to K
+var ErrBadJSON = errors.New("invalid JSON for K")
+
+// MarshalJSON encodes k as JSON with default JSONOptions
+func (k *K) MarshalJSON() ([]byte, error) {
+	return MarshalJSONWithOptions(k, JSONOptions{})
+}
+
+// MarshalJSON encodes table as JSON array of row objects
+func (tbl Table) MarshalJSON() ([]byte, error) {
+	return MarshalJSONWithOptions(&K{XT, NONE, tbl}, JSONOptions{})
+}
+
+// MarshalJSON encodes dict as JSON object
+func (d Dict) MarshalJSON() ([]byte, error) {
+	return MarshalJSONWithOptions(&K{XD, NONE, d}, JSONOptions{})
+}
+
+// MarshalJSONWithOptions encodes k as JSON according to opts
+func MarshalJSONWithOptions(k *K, opts JSONOptions) ([]byte, error) {
+	e := &jsonEncoder{opts: opts}
+	if err := e.encode(k); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type jsonEncoder struct {
+	buf  bytes.Buffer
+	opts JSONOptions
+}
+
+func (e *jsonEncoder) encode(k *K) error {
+	if k == nil {
+		e.buf.WriteString("null")
+		return nil
+	}
+	if e.opts.Typed {
+		e.buf.WriteString(`{"t":` + strconv.Itoa(int(k.Type)))
+		if k.Attr != NONE {
+			e.buf.WriteString(`,"a":` + strconv.Itoa(int(k.Attr)))
+		}
+		e.buf.WriteString(`,"v":`)
+		if err := e.value(k); err != nil {
+			return err
+		}
+		e.buf.WriteString("}")
+		return nil
+	}
+	return e.value(k)
+}
+
+func (e *jsonEncoder) value(k *K) error {
+	switch {
+	case k.Type < K0 && k.Type >= -KT:
+		e.atom(k.Type, k.Data)
+		return nil
+	case k.Type == KC:
+		e.str(k.Data.(string))
+		return nil
+	case k.Type > K0 && k.Type <= KT:
+		v := reflect.ValueOf(k.Data)
+		e.buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.buf.WriteString(",")
+			}
+			e.atom(-k.Type, v.Index(i).Interface())
+		}
+		e.buf.WriteString("]")
+		return nil
+	}
+	switch k.Type {
+	case K0, KPROJ, KCOMP:
+		if k.Type != K0 && !e.opts.Typed {
+			break
+		}
+		return e.list(k.Data.([]*K))
+	case XD, SD:
+		d := k.Data.(Dict)
+		if e.opts.Typed {
+			return e.list([]*K{d.Key, d.Value})
+		}
+		if d.Key.Type == XT && d.Value.Type == XT {
+			return e.table(mergeTables(d.Key.Data.(Table), d.Value.Data.(Table)))
+		}
+		return e.dict(d)
+	case XT:
+		t := k.Data.(Table)
+		if e.opts.Typed {
+			e.buf.WriteString(`{"c":`)
+			e.strs(t.Columns)
+			e.buf.WriteString(`,"d":`)
+			if err := e.list(t.Data); err != nil {
+				return err
+			}
+			e.buf.WriteString("}")
+			return nil
+		}
+		return e.table(t)
+	case KFUNC:
+		if e.opts.Typed {
+			f := k.Data.(Function)
+			e.strs([]string{f.Namespace, f.Body})
+			return nil
+		}
+	case KFUNCUP, KFUNCBP, KFUNCTR:
+		if e.opts.Typed {
+			e.buf.WriteString(strconv.Itoa(int(k.Data.(byte))))
+			return nil
+		}
+	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
+		if e.opts.Typed {
+			return e.encode(k.Data.(*K))
+		}
+	case KERR:
+		e.str(k.Data.(error).Error())
+		return nil
+	default:
+		if (isEnum(k.Type) || isEnum(-k.Type)) && e.opts.Typed {
+			en := k.Data.(Enum)
+			e.buf.WriteString(`{"d":`)
+			e.str(en.Domain)
+			e.buf.WriteString(`,"i":`)
+			if en.Index == nil {
+				en.Index = []int64{}
+			}
+			e.value(&K{KJ, NONE, en.Index})
+			e.buf.WriteString("}")
+			return nil
+		}
+		if !isEnum(k.Type) && !isEnum(-k.Type) {
+			return &UnsupportedTypeError{k.Type}
+		}
+	}
+	e.str(FormatLiteral(k))
+	return nil
+}
+
+func (e *jsonEncoder) list(l []*K) error {
+	e.buf.WriteString("[")
+	for i, x := range l {
+		if i > 0 {
+			e.buf.WriteString(",")
+		}
+		if err := e.encode(x); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteString("]")
+	return nil
+}
+
+func (e *jsonEncoder) dict(d Dict) error {
+	if d.Key.Type != KS {
+		// keys without JSON counterpart are written as pairs
+		e.buf.WriteString(`{"keys":`)
+		if err := e.encode(d.Key); err != nil {
+			return err
+		}
+		e.buf.WriteString(`,"values":`)
+		if err := e.encode(d.Value); err != nil {
+			return err
+		}
+		e.buf.WriteString("}")
+		return nil
+	}
+	keys := d.Key.Data.([]string)
+	e.buf.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			e.buf.WriteString(",")
+		}
+		e.str(key)
+		e.buf.WriteString(":")
+		if err := e.encode(indexK(d.Value, i)); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteString("}")
+	return nil
+}
+
+func (e *jsonEncoder) table(t Table) error {
+	if e.opts.Columns {
+		e.buf.WriteString("{")
+		for i, c := range t.Columns {
+			if i > 0 {
+				e.buf.WriteString(",")
+			}
+			e.str(c)
+			e.buf.WriteString(":")
+			if err := e.encode(t.Data[i]); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteString("}")
+		return nil
+	}
+	n := 0
+	if len(t.Data) > 0 {
+		n = t.Data[0].Len()
+	}
+	e.buf.WriteString("[")
+	for row := 0; row < n; row++ {
+		if row > 0 {
+			e.buf.WriteString(",")
+		}
+		e.buf.WriteString("{")
+		for i, c := range t.Columns {
+			if i > 0 {
+				e.buf.WriteString(",")
+			}
+			e.str(c)
+			e.buf.WriteString(":")
+			if err := e.encode(indexK(t.Data[i], row)); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteString("}")
+	}
+	e.buf.WriteString("]")
+	return nil
+}
+
+// indexK returns i-th element of list k as K
+func indexK(k *K, i int) *K {
+	switch {
+	case k.Type == K0:
+		return k.Data.([]*K)[i]
+	case k.Type == KC:
+		return &K{-KC, NONE, k.Data.(string)[i]}
+	case k.Type > K0 && k.Type <= KT:
+		return &K{-k.Type, NONE, k.Index(i)}
+	case k.Type == XT:
+		return k.Index(i).(*K)
+	}
+	return Null(K0)
+}
+
+func mergeTables(a, b Table) Table {
+	return Table{append(append([]string{}, a.Columns...), b.Columns...), append(append([]*K{}, a.Data...), b.Data...)}
+}
+
+func (e *jsonEncoder) str(s string) {
+	if e.opts.Typed {
+		e.bytes(s)
+		return
+	}
+	b, _ := json.Marshal(s)
+	e.buf.Write(b)
+}
+
+func (e *jsonEncoder) strs(l []string) {
+	if e.opts.Typed {
+		e.buf.WriteString("[")
+		for i, s := range l {
+			if i > 0 {
+				e.buf.WriteString(",")
+			}
+			e.bytes(s)
+		}
+		e.buf.WriteString("]")
+		return
+	}
+	b, _ := json.Marshal(l)
+	e.buf.Write(b)
+}
+
+// bytes writes s as JSON string with one character per byte, escaping control and non-ASCII bytes
+// as \u00XX, so that chars and symbols which aren't valid UTF-8 survive typed round trip
+func (e *jsonEncoder) bytes(s string) {
+	const digits = "0123456789abcdef"
+	e.buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			e.buf.WriteByte('\\')
+			e.buf.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			e.buf.WriteString(`\u00`)
+			e.buf.WriteByte(digits[c>>4])
+			e.buf.WriteByte(digits[c&15])
+		default:
+			e.buf.WriteByte(c)
+		}
+	}
+	e.buf.WriteByte('"')
+}
+
+// typedString decodes string written by jsonEncoder.bytes, characters above U+00FF are rejected
+func typedString(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return "", false
+		}
+		b = append(b, byte(r))
+	}
+	return string(b), true
+}
+
+func (e *jsonEncoder) inf(sign int) {
+	switch {
+	case e.opts.InfinityAsNull:
+		e.buf.WriteString("null")
+	case sign > 0:
+		e.buf.WriteString(`"Infinity"`)
+	default:
+		e.buf.WriteString(`"-Infinity"`)
+	}
+}
+
+func (e *jsonEncoder) atom(t int8, v interface{}) {
+	switch t {
+	case -KB:
+		e.buf.WriteString(strconv.FormatBool(v.(bool)))
+		return
+	case -UU:
+		if !e.opts.Typed && v.(GUID).IsNull() {
+			e.buf.WriteString("null")
+			return
+		}
+		e.str(v.(GUID).String())
+		return
+	case -KC:
+		e.str(string([]byte{v.(byte)}))
+		return
+	case -KS:
+		e.str(v.(string))
+		return
+	}
+	i, f, isFloat := rawAtom(t, v)
+	if isFloat {
+		switch {
+		case math.IsNaN(f):
+			e.buf.WriteString("null")
+		case math.IsInf(f, 0):
+			e.inf(int(math.Copysign(1, f)))
+		case e.opts.Typed || t != -KZ:
+			e.buf.WriteString(strconv.FormatFloat(f, 'g', -1, map[bool]int{true: 32, false: 64}[t == -KE]))
+		default:
+			s, _ := isoAtom(t, v)
+			e.str(s)
+		}
+		return
+	}
+	null, inf := rawLimits(t)
+	switch {
+	case i == null && t != -KG:
+		e.buf.WriteString("null")
+	case (i == inf || i == -inf) && t != -KG:
+		e.inf(int(i / inf))
+	case e.opts.Typed:
+		e.buf.WriteString(strconv.FormatInt(i, 10))
+	default:
+		if s, ok := isoAtom(t, v); ok {
+			e.str(s)
+			return
+		}
+		switch t {
+		case -KN:
+			e.str(isoDuration(v.(time.Duration)))
+		case -KU, -KV, -KT:
+			e.str(v.(interface{ String() string }).String())
+		default:
+			e.buf.WriteString(strconv.FormatInt(i, 10))
+		}
+	}
+}
+
+// isoAtom formats timestamp, month, date or datetime v in ISO 8601
+func isoAtom(t int8, v interface{}) (string, bool) {
+	switch t {
+	case -KP:
+		return v.(time.Time).UTC().Format(time.RFC3339Nano), true
+	case -KM:
+		return v.(Month).Time().Format("2006-01"), true
+	case -KD:
+		return v.(time.Time).Format("2006-01-02"), true
+	case -KZ:
+		return v.(Datetime).Time().Format("2006-01-02T15:04:05.000Z"), true
+	}
+	return "", false
+}
+
+// isoDuration formats d as ISO 8601 duration, e.g. P1DT2H3M4.5S
+func isoDuration(d time.Duration) string {
+	sign, ns := signAbs(int64(d))
+	day := int64(24 * time.Hour)
+	s := sign + "P"
+	if ns >= day {
+		s += strconv.FormatInt(ns/day, 10) + "D"
+	}
+	ns %= day
+	s += "T"
+	if h := ns / int64(time.Hour); h > 0 {
+		s += strconv.FormatInt(h, 10) + "H"
+	}
+	if m := ns / int64(time.Minute) % 60; m > 0 {
+		s += strconv.FormatInt(m, 10) + "M"
+	}
+	return s + strconv.FormatFloat(float64(ns%int64(time.Minute))/1e9, 'f', -1, 64) + "S"
+}
+
+// rawAtom returns q representation of atom, integer or float
+func rawAtom(t int8, v interface{}) (int64, float64, bool) {
+	switch t {
+	case -KG:
+		return int64(v.(byte)), 0, false
+	case -KH:
+		return int64(v.(int16)), 0, false
+	case -KI:
+		return int64(v.(int32)), 0, false
+	case -KJ:
+		return v.(int64), 0, false
+	case -KE:
+		return 0, float64(v.(float32)), true
+	case -KF:
+		return 0, v.(float64), true
+	case -KZ:
+		return 0, float64(v.(Datetime)), true
+	case -KP:
+		return toTimestamp(v.(time.Time)), 0, false
+	case -KD:
+		return int64(toDate(v.(time.Time))), 0, false
+	case -KN:
+		return int64(v.(time.Duration)), 0, false
+	case -KM:
+		return int64(v.(Month)), 0, false
+	case -KU:
+		return int64(v.(Minute)), 0, false
+	case -KV:
+		return int64(v.(Second)), 0, false
+	case -KT:
+		return int64(v.(Time)), 0, false
+	}
+	return 0, 0, false
+}
+
+// fromRaw is inverse of rawAtom
+func fromRaw(t int8, i int64, f float64) interface{} {
+	switch t {
+	case -KG:
+		return byte(i)
+	case -KH:
+		return int16(i)
+	case -KI:
+		return int32(i)
+	case -KJ:
+		return i
+	case -KE:
+		return float32(f)
+	case -KF:
+		return f
+	case -KZ:
+		return Datetime(f)
+	case -KP:
+		return fromTimestamp(i)
+	case -KD:
+		return fromDate(int32(i))
+	case -KN:
+		return time.Duration(i)
+	case -KM:
+		return Month(i)
+	case -KU:
+		return Minute(i)
+	case -KV:
+		return Second(i)
+	case -KT:
+		return Time(i)
+	}
+	return nil
+}
+
+// rawLimits returns null and infinity of integer atom type t
+func rawLimits(t int8) (int64, int64) {
+	switch t {
+	case -KH:
+		return int64(Nh), int64(Wh)
+	case -KJ, -KP, -KN:
+		return Nj, Wj
+	}
+	return int64(Ni), int64(Wi)
+}
+
+// UnmarshalJSON decodes k from JSON the same way as q's .j.k: numbers to floats, strings to char vectors,
+// objects to dicts with symbol keys and arrays of objects with the same keys to tables.
+// Typed form is decoded only by UnmarshalJSONWithOptions.
+func (k *K) UnmarshalJSON(b []byte) error {
+	res, err := UnmarshalJSONWithOptions(b, JSONOptions{})
+	if err != nil {
+		return err
+	}
+	*k = *res
+	return nil
+}
+
+// UnmarshalJSON decodes table from JSON array of row objects or object of columns
+func (tbl *Table) UnmarshalJSON(b []byte) error {
+	k, err := UnmarshalJSONWithOptions(b, JSONOptions{})
+	if err != nil {
+		return err
+	}
+	if k.Type != XT {
+		if k.Type != XD || k.Data.(Dict).Key.Type != KS || k.Data.(Dict).Value.Type != K0 {
+			return ErrBadJSON
+		}
+		d := k.Data.(Dict)
+		k = NewTable(d.Key.Data.([]string), d.Value.Data.([]*K))
+		if Validate(k) != nil {
+			return ErrBadJSON
+		}
+	}
+	*tbl = k.Data.(Table)
+	return nil
+}
+
+// UnmarshalJSON decodes dict from JSON object
+func (d *Dict) UnmarshalJSON(b []byte) error {
+	k, err := UnmarshalJSONWithOptions(b, JSONOptions{})
+	if err != nil {
+		return err
+	}
+	if k.Type != XD && k.Type != SD {
+		return ErrBadJSON
+	}
+	*d = k.Data.(Dict)
+	return nil
+}
+
+// UnmarshalJSONWithOptions decodes JSON to K. Only Typed option is used,
+// it requires input in typed form as written by MarshalJSONWithOptions.
+func UnmarshalJSONWithOptions(b []byte, opts JSONOptions) (*K, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := readJSON(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrBadJSON
+	}
+	if opts.Typed {
+		k, err := fromTypedJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := Validate(k); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	return fromJSON(v), nil
+}
+
+// jsonObject is JSON object with keys in document order
+type jsonObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o jsonObject) get(key string) (interface{}, bool) {
+	for i, k := range o.keys {
+		if k == key {
+			return o.values[i], true
+		}
+	}
+	return nil, false
+}
+
+// readJSON reads next JSON value keeping order of object keys
+func readJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := readJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	case json.Delim('{'):
+		var obj jsonObject
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := readJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key.(string))
+			obj.values = append(obj.values, v)
+		}
+		_, err = dec.Token()
+		return obj, err
+	}
+	return tok, nil
+}
+
+func fromJSON(v interface{}) *K {
+	switch x := v.(type) {
+	case bool:
+		return &K{-KB, NONE, x}
+	case json.Number:
+		f, _ := strconv.ParseFloat(string(x), 64)
+		return Float(f)
+	case string:
+		return &K{KC, NONE, x}
+	case []interface{}:
+		if t := tableFromRows(x); t != nil {
+			return t
+		}
+		return collapseJSON(x)
+	case jsonObject:
+		return NewDict(SymbolV(append([]string{}, x.keys...)), collapseJSON(x.values))
+	}
+	return Float(Nf)
+}
+
+// collapseJSON converts values and turns list of floats or booleans into vector
+func collapseJSON(values []interface{}) *K {
+	items := make([]*K, len(values))
+	for i, v := range values {
+		items[i] = fromJSON(v)
+	}
+	if len(items) == 0 || items[0].Type != -KF && items[0].Type != -KB {
+		return NewList(items...)
+	}
+	return collapse(items)
+}
+
+// tableFromRows returns table if rows are objects with the same keys
+func tableFromRows(rows []interface{}) *K {
+	if len(rows) == 0 {
+		return nil
+	}
+	first, ok := rows[0].(jsonObject)
+	if !ok || len(first.keys) == 0 {
+		return nil
+	}
+	cols := make([][]interface{}, len(first.keys))
+	for _, r := range rows {
+		row, ok := r.(jsonObject)
+		if !ok || !reflect.DeepEqual(row.keys, first.keys) {
+			return nil
+		}
+		for i, v := range row.values {
+			cols[i] = append(cols[i], v)
+		}
+	}
+	data := make([]*K, len(cols))
+	for i, c := range cols {
+		data[i] = collapseJSON(c)
+	}
+	return NewTable(append([]string{}, first.keys...), data)
+}
+
+func fromTypedJSON(v interface{}) (*K, error) {
+	obj, ok := v.(jsonObject)
+	if !ok {
+		return nil, ErrBadJSON
+	}
+	tv, ok := obj.get("t")
+	tn, isNum := tv.(json.Number)
+	val, hasValue := obj.get("v")
+	if !ok || !isNum || !hasValue {
+		return nil, ErrBadJSON
+	}
+	t64, err := strconv.ParseInt(string(tn), 10, 8)
+	if err != nil {
+		return nil, ErrBadJSON
+	}
+	t := int8(t64)
+	var attr Attr
+	if av, ok := obj.get("a"); ok {
+		an, _ := av.(json.Number)
+		a, err := strconv.ParseInt(string(an), 10, 8)
+		if err != nil {
+			return nil, ErrBadJSON
+		}
+		attr = Attr(a)
+	}
+	k, err := typedValue(t, val)
+	if err != nil {
+		return nil, err
+	}
+	k.Attr = attr
+	return k, nil
+}
+
+func typedList(v interface{}) ([]*K, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrBadJSON
+	}
+	l := make([]*K, len(arr))
+	for i, x := range arr {
+		k, err := fromTypedJSON(x)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = k
+	}
+	return l, nil
+}
+
+func typedValue(t int8, v interface{}) (*K, error) {
+	switch {
+	case t < K0 && t >= -KT:
+		x, err := atomFromJSON(t, v)
+		if err != nil {
+			return nil, err
+		}
+		return &K{t, NONE, x}, nil
+	case t == KC:
+		s, ok := typedString(v)
+		if !ok {
+			return nil, ErrBadJSON
+		}
+		return &K{KC, NONE, s}, nil
+	case t > K0 && t <= KT:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, ErrBadJSON
+		}
+		vec := emptyVector(t)
+		for _, e := range arr {
+			x, err := atomFromJSON(-t, e)
+			if err != nil {
+				return nil, err
+			}
+			vec = appendValue(vec, x)
+		}
+		return &K{t, NONE, vec}, nil
+	case isEnum(t) || isEnum(-t):
+		obj, ok := v.(jsonObject)
+		if !ok {
+			return nil, ErrBadJSON
+		}
+		dv, _ := obj.get("d")
+		domain, ok := typedString(dv)
+		iv, _ := obj.get("i")
+		index, err := typedValue(KJ, iv)
+		if !ok || err != nil {
+			return nil, ErrBadJSON
+		}
+		return &K{t, NONE, Enum{domain, index.Data.([]int64)}}, nil
+	}
+	switch t {
+	case K0, KPROJ, KCOMP:
+		l, err := typedList(v)
+		if err != nil {
+			return nil, err
+		}
+		return &K{t, NONE, l}, nil
+	case XD, SD:
+		l, err := typedList(v)
+		if err != nil || len(l) != 2 {
+			return nil, ErrBadJSON
+		}
+		return &K{t, NONE, Dict{l[0], l[1]}}, nil
+	case XT:
+		obj, ok := v.(jsonObject)
+		if !ok {
+			return nil, ErrBadJSON
+		}
+		cv, _ := obj.get("c")
+		cols, err := typedValue(KS, cv)
+		if err != nil {
+			return nil, err
+		}
+		dv, _ := obj.get("d")
+		data, err := typedList(dv)
+		if err != nil {
+			return nil, err
+		}
+		return NewTable(cols.Data.([]string), data), nil
+	case KFUNC:
+		f, err := typedValue(KS, v)
+		if err != nil || f.Len() != 2 {
+			return nil, ErrBadJSON
+		}
+		parts := f.Data.([]string)
+		return NewFunc(parts[0], parts[1]), nil
+	case KFUNCUP, KFUNCBP, KFUNCTR:
+		b, err := atomFromJSON(-KG, v)
+		if err != nil {
+			return nil, err
+		}
+		return &K{t, NONE, b}, nil
+	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
+		f, err := fromTypedJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return &K{t, NONE, f}, nil
+	case KERR:
+		s, ok := typedString(v)
+		if !ok {
+			return nil, ErrBadJSON
+		}
+		return Error(errors.New(s)), nil
+	}
+	return nil, &UnsupportedTypeError{t}
+}
+
+func atomFromJSON(t int8, v interface{}) (interface{}, error) {
+	switch t {
+	case -KB:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, ErrBadJSON
+	case -UU:
+		if s, ok := v.(string); ok {
+			return ParseGUID(s)
+		}
+		return nil, ErrBadJSON
+	case -KC:
+		if s, ok := typedString(v); ok && len(s) == 1 {
+			return s[0], nil
+		}
+		return nil, ErrBadJSON
+	case -KS:
+		if s, ok := typedString(v); ok {
+			return s, nil
+		}
+		return nil, ErrBadJSON
+	}
+	_, _, isFloat := rawAtom(t, Null(t).Data)
+	switch x := v.(type) {
+	case nil:
+		return Null(t).Data, nil
+	case string:
+		sign := map[string]int64{"Infinity": 1, "-Infinity": -1}[x]
+		if sign == 0 {
+			return nil, ErrBadJSON
+		}
+		_, inf := rawLimits(t)
+		return fromRaw(t, sign*inf, math.Inf(int(sign))), nil
+	case json.Number:
+		if isFloat {
+			f, err := strconv.ParseFloat(string(x), 64)
+			if err != nil {
+				return nil, ErrBadJSON
+			}
+			return fromRaw(t, 0, f), nil
+		}
+		i, err := strconv.ParseInt(string(x), 10, 64)
+		if err != nil {
+			return nil, ErrBadJSON
+		}
+		// nulls and infinities aren't numbers in JSON, values out of range would wrap into them
+		if _, inf := rawLimits(t); t == -KG && (i < 0 || i > math.MaxUint8) || t != -KG && (i <= -inf || i >= inf) {
+			return nil, ErrBadJSON
+		}
+		return fromRaw(t, i, 0), nil
+	}
+	return nil, ErrBadJSON
+}
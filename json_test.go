@@ -0,0 +1,209 @@
+package kdb
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var jsonTable = NewTable([]string{"sym", "px"}, []*K{SymbolV([]string{"a", "b"}), FloatV([]float64{1.5, Nf})})
+
+func TestMarshalJSON(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	var jsonTests = []struct {
+		k        *K
+		opts     JSONOptions
+		expected string
+	}{
+		{Long(1), JSONOptions{}, `1`},
+		{Long(Nj), JSONOptions{}, `null`},
+		{LongV([]int64{1, Wj, -Wj}), JSONOptions{}, `[1,"Infinity","-Infinity"]`},
+		{LongV([]int64{1, Wj}), JSONOptions{InfinityAsNull: true}, `[1,null]`},
+		{FloatV([]float64{1.5, Nf, Wf}), JSONOptions{}, `[1.5,null,"Infinity"]`},
+		{&K{KB, NONE, []bool{true, false}}, JSONOptions{}, `[true,false]`},
+		{&K{KC, NONE, "abc"}, JSONOptions{}, `"abc"`},
+		{SymbolV([]string{"a", "b"}), JSONOptions{}, `["a","b"]`},
+		{&K{-UU, NONE, GUID{}}, JSONOptions{}, `null`},
+		{Timestamp(ts), JSONOptions{}, `"2020-01-02T03:04:05.000000006Z"`},
+		{Timestamp(Np), JSONOptions{}, `null`},
+		{Date(ts), JSONOptions{}, `"2020-01-02"`},
+		{&K{-KM, NONE, Month(1)}, JSONOptions{}, `"2000-02"`},
+		{&K{-KZ, NONE, Datetime(0.5)}, JSONOptions{}, `"2000-01-01T12:00:00.000Z"`},
+		{Timespan(26*time.Hour + 1500*time.Millisecond), JSONOptions{}, `"P1DT2H1.5S"`},
+		{Timespan(0), JSONOptions{}, `"PT0S"`},
+		{&K{KT, NONE, []Time{1000, Nt}}, JSONOptions{}, `["00:00:01.000",null]`},
+		{NewList(Long(1), Symbol("a")), JSONOptions{}, `[1,"a"]`},
+		{NewDict(SymbolV([]string{"a", "b"}), LongV([]int64{1, 2})), JSONOptions{}, `{"a":1,"b":2}`},
+		{NewDict(LongV([]int64{1}), LongV([]int64{2})), JSONOptions{}, `{"keys":[1],"values":[2]}`},
+		{jsonTable, JSONOptions{}, `[{"sym":"a","px":1.5},{"sym":"b","px":null}]`},
+		{jsonTable, JSONOptions{Columns: true}, `{"sym":["a","b"],"px":[1.5,null]}`},
+		{NewDict(NewTable([]string{"k"}, []*K{LongV([]int64{1})}), NewTable([]string{"v"}, []*K{SymbolV([]string{"x"})})),
+			JSONOptions{}, `[{"k":1,"v":"x"}]`},
+		{NewFunc("", "{x+1}"), JSONOptions{}, `"{x+1}"`},
+		{Error(errors.New("type")), JSONOptions{}, `"type"`},
+		{&K{-KB, NONE, true}, JSONOptions{Typed: true}, `{"t":-1,"v":true}`},
+		{&K{KS, SORTED, []string{"a"}}, JSONOptions{Typed: true}, `{"t":11,"a":1,"v":["a"]}`},
+		{Date(ts), JSONOptions{Typed: true}, `{"t":-14,"v":7306}`},
+	}
+	for _, tt := range jsonTests {
+		b, err := MarshalJSONWithOptions(tt.k, tt.opts)
+		if err != nil {
+			t.Errorf("MarshalJSON(%v): %v", tt.k, err)
+			continue
+		}
+		if string(b) != tt.expected {
+			t.Errorf("MarshalJSON(%v): expected %s, got %s", tt.k, tt.expected, b)
+		}
+	}
+}
+
+func TestTypedJSONRoundtrip(t *testing.T) {
+	var objects = []*K{
+		NewList(LongV([]int64{1, Nj, Wj}), FloatV([]float64{Nf, -Wf}), &K{-KE, NONE, We}),
+		&K{KG, NONE, []byte{0, 255}},
+		&K{KC, NONE, "a\"b"},
+		&K{-KC, NONE, byte('x')},
+		&K{UU, NONE, []GUID{{}, testGUID}},
+		TimestampV([]time.Time{Np, Wp, time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)}),
+		&K{KZ, NONE, []Datetime{Nz, 0.123456789}},
+		&K{KN, NONE, []time.Duration{Nn, 1}},
+		&K{KM, NONE, []Month{Nm, 5}},
+		&K{KU, NONE, []Minute{Nu, 5}},
+		&K{KV, NONE, []Second{-Wv, 5}},
+		NewSortedDict(&K{KS, SORTED, []string{"a"}}, LongV([]int64{1})),
+		jsonTable,
+		NewProjection(NewFunc(".ns", "{x+y}"), nil, Long(1)),
+		Over(mustPrimitive(t, "+")),
+		Enumeration(20, "sym", []int64{0, Nj}),
+	}
+	for _, k := range objects {
+		b, err := MarshalJSONWithOptions(k, JSONOptions{Typed: true})
+		if err != nil {
+			t.Errorf("MarshalJSON(%v): %v", k, err)
+			continue
+		}
+		d, err := UnmarshalJSONWithOptions(b, JSONOptions{Typed: true})
+		if err != nil {
+			t.Errorf("UnmarshalJSON(%s): %v", b, err)
+			continue
+		}
+		// compare encoded form as NaN is not equal to itself
+		b2, _ := MarshalJSONWithOptions(d, JSONOptions{Typed: true})
+		if string(b) != string(b2) || Validate(d) != nil {
+			t.Errorf("roundtrip of %s gave %s", b, b2)
+		}
+	}
+}
+
+func TestTypedJSONBytes(t *testing.T) {
+	var objects = []*K{
+		&K{KC, NONE, "caf\xe9"},
+		&K{-KC, NONE, byte(0xe9)},
+		Symbol("\xff"),
+		SymbolV([]string{"\xc3\xa9", "\x00\x7f"}),
+		NewTable([]string{"\xe9"}, []*K{&K{KC, NONE, "\x80"}}),
+		Enumeration(20, "\xfe", []int64{0}),
+	}
+	for _, k := range objects {
+		b, err := MarshalJSONWithOptions(k, JSONOptions{Typed: true})
+		if err != nil {
+			t.Errorf("MarshalJSON(%v): %v", k, err)
+			continue
+		}
+		if !utf8.Valid(b) {
+			t.Errorf("MarshalJSON(%v): invalid UTF-8 %q", k, b)
+		}
+		d, err := UnmarshalJSONWithOptions(b, JSONOptions{Typed: true})
+		if err != nil {
+			t.Errorf("UnmarshalJSON(%s): %v", b, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, k) {
+			t.Errorf("roundtrip of %#v gave %#v", k, d)
+		}
+	}
+	b, _ := MarshalJSONWithOptions(&K{KC, NONE, "caf\xe9"}, JSONOptions{Typed: true})
+	if s := `{"t":10,"v":"caf\u00e9"}`; string(b) != s {
+		t.Errorf("expected %s, got %s", s, b)
+	}
+	if _, err := UnmarshalJSONWithOptions([]byte(`{"t":-11,"v":"\u20ac"}`), JSONOptions{Typed: true}); err != ErrBadJSON {
+		t.Errorf("expected %v for character above U+00FF, got %v", ErrBadJSON, err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var jsonTests = []struct {
+		s        string
+		expected *K
+	}{
+		{`1`, Float(1)},
+		{`"ab"`, &K{KC, NONE, "ab"}},
+		{`[1,2]`, FloatV([]float64{1, 2})},
+		{`[true,false]`, &K{KB, NONE, []bool{true, false}}},
+		{`[1,"a"]`, NewList(Float(1), &K{KC, NONE, "a"})},
+		{`[]`, &K{K0, NONE, []*K{}}},
+		{`{"b":1,"a":"x"}`, NewDict(SymbolV([]string{"b", "a"}), NewList(Float(1), &K{KC, NONE, "x"}))},
+		{`[{"s":"a","p":1},{"s":"b","p":2}]`, NewTable([]string{"s", "p"}, []*K{NewList(&K{KC, NONE, "a"}, &K{KC, NONE, "b"}), FloatV([]float64{1, 2})})},
+		{`{"t":1,"v":"x"}`, NewDict(SymbolV([]string{"t", "v"}), NewList(Float(1), &K{KC, NONE, "x"}))},
+		{`[{"s":"a"},{"t":"b"}]`, NewList(NewDict(SymbolV([]string{"s"}), NewList(&K{KC, NONE, "a"})), NewDict(SymbolV([]string{"t"}), NewList(&K{KC, NONE, "b"})))},
+	}
+	for _, tt := range jsonTests {
+		var k K
+		if err := json.Unmarshal([]byte(tt.s), &k); err != nil {
+			t.Errorf("UnmarshalJSON(%s): %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(&k, tt.expected) {
+			t.Errorf("UnmarshalJSON(%s): expected %v, got %v", tt.s, tt.expected, &k)
+		}
+	}
+	if _, err := UnmarshalJSONWithOptions([]byte(`{"t":77,"v":1}`), JSONOptions{Typed: true}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+	if _, err := UnmarshalJSONWithOptions([]byte(`{"t":98,"v":{"c":["a","b"],"d":[{"t":7,"v":[1]}]}}`), JSONOptions{Typed: true}); err == nil {
+		t.Error("expected error for invalid table")
+	}
+	// out of range values would wrap around, possibly into null or infinity
+	for _, s := range []string{`{"t":-5,"v":100000}`, `{"t":-5,"v":-32768}`, `{"t":-4,"v":256}`, `{"t":-4,"v":-1}`,
+		`{"t":6,"v":[1,2147483647]}`, `{"t":-14,"v":-2147483649}`, `{"t":-7,"v":-9223372036854775808}`} {
+		if _, err := UnmarshalJSONWithOptions([]byte(s), JSONOptions{Typed: true}); err != ErrBadJSON {
+			t.Errorf("UnmarshalJSON(%s): expected %v, got %v", s, ErrBadJSON, err)
+		}
+	}
+}
+
+func TestTableJSON(t *testing.T) {
+	v := struct {
+		T Table
+		D Dict
+	}{jsonTable.Data.(Table), NewDict(SymbolV([]string{"a"}), LongV([]int64{1})).Data.(Dict)}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"T":[{"sym":"a","px":1.5},{"sym":"b","px":null}],"D":{"a":1}}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+	var tbl Table
+	if err := json.Unmarshal([]byte(`{"a":[1,2],"b":["x","y"]}`), &tbl); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tbl.Columns, []string{"a", "b"}) || tbl.Data[0].Len() != 2 {
+		t.Errorf("unexpected table %v", tbl)
+	}
+}
+
+func FuzzUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"t":0,"v":[{"t":-7,"v":1},{"t":98,"v":{"c":["a"],"d":[{"t":7,"v":[1]}]}}]}`))
+	f.Add([]byte(`[{"a":1},{"a":null}]`))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var k K
+		if json.Unmarshal(b, &k) == nil {
+			k.MarshalJSON()
+		}
+	})
+}
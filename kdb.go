@@ -24,6 +24,7 @@ type KDBConn struct {
 	Host    string
 	Port    string
 	userpwd string
+	opts    DecodeOptions
 }
 
 // Close connection to the server
@@ -38,8 +39,30 @@ func (c *KDBConn) ok() bool {
 	return c.con != nil
 }
 
+// SetDecodeOptions limits resources messages read from the connection may consume.
+// Connection is closed when a message exceeds the limits as the rest of it is left unread.
+func (c *KDBConn) SetDecodeOptions(opts DecodeOptions) {
+	c.opts = opts
+}
+
+// decode reads message with connection's DecodeOptions
+func (c *KDBConn) decode() (*K, ReqType, error) {
+	data, msgtype, err := DecodeWithOptions(c.rbuf, c.opts)
+	var limit *LimitError
+	if errors.As(err, &limit) {
+		c.con.Close()
+	}
+	return data, msgtype, err
+}
+
 // process clients requests
 func HandleClientConnection(conn net.Conn) {
+	HandleClientConnectionWithOptions(conn, DecodeOptions{})
+}
+
+// HandleClientConnectionWithOptions processes clients requests decoding them with opts.
+// Connection is closed on the first message which can't be decoded.
+func HandleClientConnectionWithOptions(conn net.Conn, opts DecodeOptions) {
 	c := conn.(*net.TCPConn)
 	c.SetKeepAlive(true)
 	c.SetNoDelay(true)
@@ -53,9 +76,12 @@ func HandleClientConnection(conn net.Conn) {
 	rbuf := bufio.NewReaderSize(conn, 4*1024*1024)
 	i := 0
 	for {
-		_, msgtype, err := Decode(rbuf)
+		_, msgtype, err := DecodeWithOptions(rbuf, opts)
 
-		if err == io.EOF {
+		if err != nil {
+			if msgtype == SYNC && err != io.EOF {
+				Encode(conn, RESPONSE, Error(err))
+			}
 			conn.Close()
 			return
 		}
@@ -83,7 +109,7 @@ func (c *KDBConn) Call(cmd string, args ...*K) (data *K, err error) {
 	if err != nil {
 		return nil, err
 	}
-	data, _, err = Decode(c.rbuf)
+	data, _, err = c.decode()
 	return data, err
 }
 
@@ -109,7 +135,7 @@ func (c *KDBConn) Response(data *K) (err error) {
 
 // ReadMessage reads complete message from connection
 func (c *KDBConn) ReadMessage() (data *K, msgtype ReqType, e error) {
-	return Decode(c.rbuf)
+	return c.decode()
 }
 
 // WriteMessage sends data in Q IPC format
@@ -158,7 +184,7 @@ func DialTLS(host string, port int, auth string, cfg *tls.Config) (*KDBConn, err
 	if err != nil {
 		return nil, err
 	}
-	kdbconn := KDBConn{c, bufio.NewReader(c), "", string(port), auth}
+	kdbconn := KDBConn{con: c, rbuf: bufio.NewReader(c), Port: fmt.Sprint(port), userpwd: auth}
 	return &kdbconn, nil
 }
 
@@ -188,7 +214,7 @@ func DialUnix(host string, port int, auth string) (*KDBConn, error) {
 	return &KDBConn{
 		con:     c,
 		rbuf:    bufio.NewReader(c),
-		Port:    string(port),
+		Port:    fmt.Sprint(port),
 		userpwd: auth,
 	}, nil
 }
@@ -205,6 +231,6 @@ func DialKDBTimeout(host string, port int, auth string, timeout time.Duration) (
 		return nil, err
 	}
 	_ = c.SetKeepAlive(true) // care if keepalive is failed to be set?
-	kdbconn := KDBConn{c, bufio.NewReader(c), host, fmt.Sprint(port), auth}
+	kdbconn := KDBConn{con: c, rbuf: bufio.NewReader(c), Host: host, Port: fmt.Sprint(port), userpwd: auth}
 	return &kdbconn, nil
 }
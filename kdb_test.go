@@ -11,6 +11,7 @@ import (
 	"strconv"
 	//"io/ioutil"
 	"os/exec"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -161,6 +162,28 @@ func TestSyncCallCompress(t *testing.T) {
 	}
 }
 
+func TestCallParamsAssign(t *testing.T) {
+	con, err := DialKDB(testHost, testPort, "")
+	if err != nil {
+		t.Fatalf("Failed to connect to test instance: %s", err)
+	}
+	defer con.Close()
+	if _, err := con.Call("kdbgoLocal:kdbgoGlobal:0"); err != nil {
+		t.Fatal(err)
+	}
+	// template runs inside lambda, single colon assigns local variable
+	if _, err := con.CallParams("kdbgoLocal:$1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := con.CallParams("kdbgoGlobal::$1", 2); err != nil {
+		t.Fatal(err)
+	}
+	res, err := con.Call("(kdbgoLocal;kdbgoGlobal)")
+	if expected := LongV([]int64{0, 2}); err != nil || !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v %v", expected, res, err)
+	}
+}
+
 func TestSyncCallUnix(t *testing.T) {
 	con, err := DialUnix(testHost, testPort, "")
 	if err != nil {
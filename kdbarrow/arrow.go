@@ -0,0 +1,688 @@
+// Package kdbarrow converts kdb+ tables to Apache Arrow records and back.
+//
+// Column types map as follows:
+//
+//	boolean   Boolean
+//	guid      FixedSizeBinary(16)
+//	byte      Uint8
+//	short     Int16
+//	int       Int32
+//	long      Int64
+//	real      Float32
+//	float     Float64
+//	char      FixedSizeBinary(1)
+//	symbol    Dictionary(Int32, String)
+//	timestamp Timestamp(ns, UTC)
+//	month     Date32, first day of the month
+//	date      Date32
+//	datetime  Timestamp(ms, UTC)
+//	timespan  Duration(ns)
+//	minute    Time32(s)
+//	second    Time32(s)
+//	time      Time32(ms)
+//	strings   String
+//
+// q nulls become Arrow nulls and infinities are clamped to the limits of Arrow type.
+// Every field carries q type in metadata under TypeKey, so that FromRecord restores
+// month, datetime and minute columns which share Arrow type with other q types.
+//
+// Columns with the same memory layout in q and Arrow, e.g. longs, floats or timespans,
+// are shared without copying in both directions. Shared memory must not be modified
+// while in use by the other side.
+package kdbarrow
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/bitutil"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/sv/kdbgo"
+)
+
+// TypeKey is field metadata key holding q type character of the column, e.g. "j"
+const TypeKey = "kdb.type"
+
+// ErrUnsupportedColumn is returned for columns without Arrow counterpart, e.g. mixed lists
+var ErrUnsupportedColumn = errors.New("unsupported column type")
+
+// q type characters indexed by vector type
+var typeChars = []string{kdb.KB: "b", kdb.UU: "g", kdb.KG: "x", kdb.KH: "h", kdb.KI: "i", kdb.KJ: "j",
+	kdb.KE: "e", kdb.KF: "f", kdb.KC: "c", kdb.KS: "s", kdb.KP: "p", kdb.KM: "m", kdb.KD: "d",
+	kdb.KZ: "z", kdb.KN: "n", kdb.KU: "u", kdb.KV: "v", kdb.KT: "t", kdb.K0: "C"}
+
+// qEpochMs is q epoch 2000.01.01 in Unix milliseconds
+const qEpochMs = 946684800000
+
+// negWp is timestamp negative infinity -0Wp
+var negWp = time.Unix(0, qEpochMs*1e6).UTC().Add(-kdb.Wn)
+
+// negWd is date negative infinity -0Wd
+var negWd = time.Unix(0, qEpochMs*1e6).UTC().AddDate(0, 0, -int(kdb.Wi))
+
+// range of timestamps representable as Unix nanoseconds
+var (
+	maxTime = time.Unix(0, math.MaxInt64)
+	minTime = time.Unix(0, math.MinInt64+1)
+)
+
+var symbolType = &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}
+
+func arrowType(k *kdb.K) (arrow.DataType, error) {
+	switch k.Type {
+	case kdb.KB:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case kdb.UU:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 16}, nil
+	case kdb.KG:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case kdb.KH:
+		return arrow.PrimitiveTypes.Int16, nil
+	case kdb.KI:
+		return arrow.PrimitiveTypes.Int32, nil
+	case kdb.KJ:
+		return arrow.PrimitiveTypes.Int64, nil
+	case kdb.KE:
+		return arrow.PrimitiveTypes.Float32, nil
+	case kdb.KF:
+		return arrow.PrimitiveTypes.Float64, nil
+	case kdb.KC:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 1}, nil
+	case kdb.KS:
+		return symbolType, nil
+	case kdb.KP:
+		return arrow.FixedWidthTypes.Timestamp_ns, nil
+	case kdb.KM, kdb.KD:
+		return arrow.FixedWidthTypes.Date32, nil
+	case kdb.KZ:
+		return arrow.FixedWidthTypes.Timestamp_ms, nil
+	case kdb.KN:
+		return arrow.FixedWidthTypes.Duration_ns, nil
+	case kdb.KU, kdb.KV:
+		return arrow.FixedWidthTypes.Time32s, nil
+	case kdb.KT:
+		return arrow.FixedWidthTypes.Time32ms, nil
+	case kdb.K0:
+		for _, s := range k.Data.([]*kdb.K) {
+			if s.Type != kdb.KC && s.Type != -kdb.KC {
+				return nil, ErrUnsupportedColumn
+			}
+		}
+		return arrow.BinaryTypes.String, nil
+	}
+	return nil, ErrUnsupportedColumn
+}
+
+// Schema returns Arrow schema of table t
+func Schema(t kdb.Table) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(t.Columns))
+	for i, c := range t.Columns {
+		dt, err := arrowType(t.Data[i])
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = arrow.Field{Name: c, Type: dt, Nullable: true,
+			Metadata: arrow.NewMetadata([]string{TypeKey}, []string{typeChars[t.Data[i].Type]})}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// NewRecord converts table t to Arrow record. Nil mem uses memory.DefaultAllocator.
+func NewRecord(t kdb.Table, mem memory.Allocator) (arrow.Record, error) {
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	schema, err := Schema(t)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]arrow.Array, len(t.Data))
+	defer func() {
+		for _, c := range cols {
+			if c != nil {
+				c.Release()
+			}
+		}
+	}()
+	var n int64
+	for i, c := range t.Data {
+		cols[i] = toArrow(c, schema.Field(i).Type, mem)
+		n = int64(cols[i].Len())
+	}
+	return array.NewRecord(schema, cols, n), nil
+}
+
+// nullBitmap returns validity bitmap for n values or nil if all of them are valid
+func nullBitmap(n int, isNull func(i int) bool, mem memory.Allocator) (*memory.Buffer, int) {
+	var buf *memory.Buffer
+	nulls := 0
+	for i := 0; i < n; i++ {
+		if !isNull(i) {
+			continue
+		}
+		if buf == nil {
+			buf = memory.NewResizableBuffer(mem)
+			buf.Resize(int(bitutil.BytesForBits(int64(n))))
+			bitutil.SetBitsTo(buf.Bytes(), 0, int64(n), true)
+		}
+		bitutil.ClearBit(buf.Bytes(), i)
+		nulls++
+	}
+	return buf, nulls
+}
+
+// fixedWidth makes array of dt sharing data
+func fixedWidth(dt arrow.DataType, n int, data []byte, isNull func(i int) bool, mem memory.Allocator) arrow.Array {
+	bitmap, nulls := nullBitmap(n, isNull, mem)
+	d := array.NewData(dt, n, []*memory.Buffer{bitmap, memory.NewBufferBytes(data)}, nil, nulls, 0)
+	defer d.Release()
+	if bitmap != nil {
+		bitmap.Release()
+	}
+	return array.MakeFromData(d)
+}
+
+func bytesOf(slice interface{}) []byte {
+	v := reflect.ValueOf(slice)
+	if v.Len() == 0 {
+		return []byte{}
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(v.Pointer())), v.Len()*int(v.Type().Elem().Size()))
+}
+
+func clamp(v, lo, hi int64) int64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func toArrow(k *kdb.K, dt arrow.DataType, mem memory.Allocator) arrow.Array {
+	never := func(int) bool { return false }
+	switch k.Type {
+	case kdb.KB:
+		b := array.NewBooleanBuilder(mem)
+		defer b.Release()
+		b.AppendValues(k.Data.([]bool), nil)
+		return b.NewArray()
+	case kdb.UU:
+		v := k.Data.([]kdb.GUID)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i].IsNull() }, mem)
+	case kdb.KG:
+		v := k.Data.([]byte)
+		return fixedWidth(dt, len(v), v, never, mem)
+	case kdb.KH:
+		v := k.Data.([]int16)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Nh }, mem)
+	case kdb.KI:
+		v := k.Data.([]int32)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Ni }, mem)
+	case kdb.KJ:
+		v := k.Data.([]int64)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Nj }, mem)
+	case kdb.KE:
+		v := k.Data.([]float32)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] != v[i] }, mem)
+	case kdb.KF:
+		v := k.Data.([]float64)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return math.IsNaN(v[i]) }, mem)
+	case kdb.KC:
+		v := []byte(k.Data.(string))
+		return fixedWidth(dt, len(v), v, never, mem)
+	case kdb.KS:
+		return symbols(k.Data.([]string), mem)
+	case kdb.KP:
+		v := k.Data.([]time.Time)
+		ns := make([]int64, len(v))
+		for i, t := range v {
+			switch {
+			case t.IsZero():
+			case t.After(maxTime):
+				ns[i] = math.MaxInt64
+			case t.Before(minTime):
+				ns[i] = math.MinInt64 + 1
+			default:
+				ns[i] = t.UnixNano()
+			}
+		}
+		return fixedWidth(dt, len(v), bytesOf(ns), func(i int) bool { return v[i].IsZero() }, mem)
+	case kdb.KM:
+		v := k.Data.([]kdb.Month)
+		days := make([]int32, len(v))
+		for i, m := range v {
+			switch m {
+			case kdb.Nm:
+			case kdb.Wm:
+				days[i] = math.MaxInt32
+			case -kdb.Wm:
+				days[i] = math.MinInt32 + 1
+			default:
+				days[i] = int32(clamp(m.Time().Unix()/86400, math.MinInt32+1, math.MaxInt32))
+			}
+		}
+		return fixedWidth(dt, len(v), bytesOf(days), func(i int) bool { return v[i] == kdb.Nm }, mem)
+	case kdb.KD:
+		v := k.Data.([]time.Time)
+		days := make([]int32, len(v))
+		for i, d := range v {
+			switch {
+			case d.IsZero():
+			case d.Equal(negWd):
+				days[i] = math.MinInt32 + 1
+			default:
+				days[i] = int32(clamp(int64(math.Floor(float64(d.Unix())/86400)), math.MinInt32+1, math.MaxInt32))
+			}
+		}
+		return fixedWidth(dt, len(v), bytesOf(days), func(i int) bool { return v[i].IsZero() }, mem)
+	case kdb.KZ:
+		v := k.Data.([]kdb.Datetime)
+		ms := make([]int64, len(v))
+		for i, z := range v {
+			f := float64(z)*86400000 + qEpochMs
+			switch {
+			case z.IsNull():
+			case f >= math.MaxInt64:
+				ms[i] = math.MaxInt64
+			case f <= math.MinInt64:
+				ms[i] = math.MinInt64 + 1
+			default:
+				ms[i] = int64(math.Round(f))
+			}
+		}
+		return fixedWidth(dt, len(v), bytesOf(ms), func(i int) bool { return v[i].IsNull() }, mem)
+	case kdb.KN:
+		v := k.Data.([]time.Duration)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Nn }, mem)
+	case kdb.KU:
+		v := k.Data.([]kdb.Minute)
+		secs := make([]int32, len(v))
+		for i, m := range v {
+			secs[i] = int32(clamp(int64(m)*60, math.MinInt32+1, math.MaxInt32))
+		}
+		return fixedWidth(dt, len(v), bytesOf(secs), func(i int) bool { return v[i] == kdb.Nu }, mem)
+	case kdb.KV:
+		v := k.Data.([]kdb.Second)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Nv }, mem)
+	case kdb.KT:
+		v := k.Data.([]kdb.Time)
+		return fixedWidth(dt, len(v), bytesOf(v), func(i int) bool { return v[i] == kdb.Nt }, mem)
+	}
+	// list of strings
+	b := array.NewStringBuilder(mem)
+	defer b.Release()
+	for _, s := range k.Data.([]*kdb.K) {
+		if s.Type == -kdb.KC {
+			b.Append(string([]byte{s.Data.(byte)}))
+		} else {
+			b.Append(s.Data.(string))
+		}
+	}
+	return b.NewArray()
+}
+
+// symbols makes dictionary encoded array of syms, null symbols are kept as empty strings
+func symbols(syms []string, mem memory.Allocator) arrow.Array {
+	index := make([]int32, len(syms))
+	seen := make(map[string]int32)
+	b := array.NewStringBuilder(mem)
+	defer b.Release()
+	for i, s := range syms {
+		j, ok := seen[s]
+		if !ok {
+			j = int32(len(seen))
+			seen[s] = j
+			b.Append(s)
+		}
+		index[i] = j
+	}
+	dict := b.NewArray()
+	defer dict.Release()
+	indices := fixedWidth(arrow.PrimitiveTypes.Int32, len(index), bytesOf(index), func(int) bool { return false }, mem)
+	defer indices.Release()
+	return array.NewDictionaryArray(symbolType, indices, dict)
+}
+
+// FromRecord converts Arrow record to q table
+func FromRecord(rec arrow.Record) (*kdb.K, error) {
+	schema := rec.Schema()
+	cols := make([]string, rec.NumCols())
+	data := make([]*kdb.K, rec.NumCols())
+	for i := range cols {
+		f := schema.Field(i)
+		cols[i] = f.Name
+		var qtype string
+		if j := f.Metadata.FindKey(TypeKey); j >= 0 {
+			qtype = f.Metadata.Values()[j]
+		}
+		col, err := fromArrow(rec.Column(i), qtype)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = col
+	}
+	return kdb.NewTable(cols, data), nil
+}
+
+func fromArrow(a arrow.Array, qtype string) (*kdb.K, error) {
+	n := a.Len()
+	switch a := a.(type) {
+	case *array.Boolean:
+		v := make([]bool, n)
+		for i := range v {
+			v[i] = a.Value(i)
+		}
+		return &kdb.K{Type: kdb.KB, Attr: kdb.NONE, Data: v}, nil
+	case *array.FixedSizeBinary:
+		switch a.DataType().(*arrow.FixedSizeBinaryType).ByteWidth {
+		case 16:
+			v := make([]kdb.GUID, n)
+			for i := range v {
+				if a.IsValid(i) {
+					copy(v[i][:], a.Value(i))
+				}
+			}
+			return &kdb.K{Type: kdb.UU, Attr: kdb.NONE, Data: v}, nil
+		case 1:
+			v := make([]byte, n)
+			for i := range v {
+				v[i] = ' '
+				if a.IsValid(i) {
+					v[i] = a.Value(i)[0]
+				}
+			}
+			return &kdb.K{Type: kdb.KC, Attr: kdb.NONE, Data: string(v)}, nil
+		}
+	case *array.Uint8:
+		return &kdb.K{Type: kdb.KG, Attr: kdb.NONE, Data: values(a, a.Uint8Values(), byte(0))}, nil
+	case *array.Int16:
+		return &kdb.K{Type: kdb.KH, Attr: kdb.NONE, Data: values(a, a.Int16Values(), kdb.Nh)}, nil
+	case *array.Int32:
+		return &kdb.K{Type: kdb.KI, Attr: kdb.NONE, Data: values(a, a.Int32Values(), kdb.Ni)}, nil
+	case *array.Int64:
+		return &kdb.K{Type: kdb.KJ, Attr: kdb.NONE, Data: values(a, a.Int64Values(), kdb.Nj)}, nil
+	case *array.Float32:
+		return &kdb.K{Type: kdb.KE, Attr: kdb.NONE, Data: values(a, a.Float32Values(), kdb.Ne)}, nil
+	case *array.Float64:
+		return &kdb.K{Type: kdb.KF, Attr: kdb.NONE, Data: values(a, a.Float64Values(), kdb.Nf)}, nil
+	case *array.Duration:
+		unit := int64(a.DataType().(*arrow.DurationType).Unit.Multiplier())
+		v := make([]time.Duration, n)
+		for i, d := range a.DurationValues() {
+			v[i] = kdb.Nn
+			if a.IsValid(i) {
+				v[i] = time.Duration(int64(d) * unit)
+			}
+		}
+		return kdb.TimespanV(v), nil
+	case *array.Timestamp:
+		unit := a.DataType().(*arrow.TimestampType).Unit
+		if qtype == "z" {
+			v := make([]kdb.Datetime, n)
+			for i, ts := range a.TimestampValues() {
+				switch {
+				case !a.IsValid(i):
+					v[i] = kdb.Nz
+				case ts == math.MaxInt64:
+					v[i] = kdb.Wz
+				case ts == math.MinInt64+1:
+					v[i] = -kdb.Wz
+				default:
+					ms := float64(ts) * float64(unit.Multiplier()) / 1e6
+					v[i] = kdb.Datetime((ms - qEpochMs) / 86400000)
+				}
+			}
+			return &kdb.K{Type: kdb.KZ, Attr: kdb.NONE, Data: v}, nil
+		}
+		v := make([]time.Time, n)
+		for i, ts := range a.TimestampValues() {
+			switch {
+			case !a.IsValid(i):
+			case ts == math.MaxInt64:
+				v[i] = kdb.Wp
+			case ts == math.MinInt64+1:
+				v[i] = negWp
+			default:
+				v[i] = ts.ToTime(unit).UTC()
+			}
+		}
+		return kdb.TimestampV(v), nil
+	case *array.Date32:
+		if qtype == "m" {
+			v := make([]kdb.Month, n)
+			for i, d := range a.Date32Values() {
+				switch {
+				case !a.IsValid(i):
+					v[i] = kdb.Nm
+				case d == math.MaxInt32:
+					v[i] = kdb.Wm
+				case d == math.MinInt32+1:
+					v[i] = -kdb.Wm
+				default:
+					v[i] = kdb.MonthOf(d.ToTime())
+				}
+			}
+			return &kdb.K{Type: kdb.KM, Attr: kdb.NONE, Data: v}, nil
+		}
+		v := make([]time.Time, n)
+		for i, d := range a.Date32Values() {
+			switch {
+			case !a.IsValid(i):
+			case d == math.MaxInt32:
+				v[i] = kdb.Wd
+			case d == math.MinInt32+1:
+				v[i] = negWd
+			default:
+				v[i] = d.ToTime()
+			}
+		}
+		return kdb.DateV(v), nil
+	case *array.Time32:
+		unit := a.DataType().(*arrow.Time32Type).Unit
+		switch {
+		case qtype == "u":
+			v := make([]kdb.Minute, n)
+			for i, t := range a.Time32Values() {
+				v[i] = kdb.Nu
+				if a.IsValid(i) {
+					v[i] = kdb.Minute(int64(t) * int64(unit.Multiplier()) / int64(time.Minute))
+				}
+			}
+			return &kdb.K{Type: kdb.KU, Attr: kdb.NONE, Data: v}, nil
+		case unit == arrow.Second:
+			v := make([]kdb.Second, n)
+			for i, t := range a.Time32Values() {
+				v[i] = kdb.Nv
+				if a.IsValid(i) {
+					v[i] = kdb.Second(t)
+				}
+			}
+			return &kdb.K{Type: kdb.KV, Attr: kdb.NONE, Data: v}, nil
+		default:
+			v := make([]kdb.Time, n)
+			for i, t := range a.Time32Values() {
+				v[i] = kdb.Nt
+				if a.IsValid(i) {
+					v[i] = kdb.Time(int64(t) * int64(unit.Multiplier()) / int64(time.Millisecond))
+				}
+			}
+			return &kdb.K{Type: kdb.KT, Attr: kdb.NONE, Data: v}, nil
+		}
+	case *array.String:
+		v := make([]*kdb.K, n)
+		for i := range v {
+			v[i] = &kdb.K{Type: kdb.KC, Attr: kdb.NONE, Data: a.Value(i)}
+		}
+		return kdb.NewList(v...), nil
+	case *array.Dictionary:
+		dict, ok := a.Dictionary().(*array.String)
+		if !ok {
+			break
+		}
+		v := make([]string, n)
+		for i := range v {
+			if a.IsValid(i) {
+				v[i] = dict.Value(a.GetValueIndex(i))
+			}
+		}
+		return kdb.SymbolV(v), nil
+	}
+	return nil, ErrUnsupportedColumn
+}
+
+// values returns v shared with a if there are no nulls, otherwise copy with nulls replaced by null
+func values[T any](a arrow.Array, v []T, null T) []T {
+	if a.NullN() == 0 {
+		return v
+	}
+	res := make([]T, len(v))
+	for i := range v {
+		if a.IsValid(i) {
+			res[i] = v[i]
+		} else {
+			res[i] = null
+		}
+	}
+	return res
+}
+
+// recordReader converts table to records batch by batch
+type recordReader struct {
+	refs   int64
+	t      kdb.Table
+	schema *arrow.Schema
+	size   int
+	off    int
+	mem    memory.Allocator
+	rec    arrow.Record
+	err    error
+}
+
+// NewRecordReader splits table t, already decoded in memory, into records of at most batchSize rows for
+// consumers of array.RecordReader. Each batch is converted when read, so that copied columns take memory
+// of one batch at a time, but the whole table stays in memory; nothing is streamed from the server.
+// Nil mem uses memory.DefaultAllocator.
+func NewRecordReader(t kdb.Table, batchSize int, mem memory.Allocator) (array.RecordReader, error) {
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	schema, err := Schema(t)
+	if err != nil {
+		return nil, err
+	}
+	return &recordReader{refs: 1, t: t, schema: schema, size: batchSize, mem: mem}, nil
+}
+
+func (r *recordReader) Retain() {
+	atomic.AddInt64(&r.refs, 1)
+}
+
+func (r *recordReader) Release() {
+	if atomic.AddInt64(&r.refs, -1) == 0 && r.rec != nil {
+		r.rec.Release()
+		r.rec = nil
+	}
+}
+
+func (r *recordReader) Schema() *arrow.Schema { return r.schema }
+
+func (r *recordReader) Record() arrow.Record { return r.rec }
+
+func (r *recordReader) Err() error { return r.err }
+
+func (r *recordReader) Next() bool {
+	if r.rec != nil {
+		r.rec.Release()
+		r.rec = nil
+	}
+	n := 0
+	if len(r.t.Data) > 0 {
+		n = r.t.Data[0].Len()
+	}
+	if r.off >= n {
+		return false
+	}
+	end := r.off + r.size
+	if end > n {
+		end = n
+	}
+	cols := make([]*kdb.K, len(r.t.Data))
+	for i, c := range r.t.Data {
+		cols[i] = slice(c, r.off, end)
+	}
+	r.off = end
+	r.rec, r.err = NewRecord(kdb.Table{Columns: r.t.Columns, Data: cols}, r.mem)
+	return r.err == nil
+}
+
+// slice returns rows i..j-1 of vector k sharing its memory
+func slice(k *kdb.K, i, j int) *kdb.K {
+	if s, ok := k.Data.(string); ok {
+		return &kdb.K{Type: k.Type, Attr: kdb.NONE, Data: s[i:j]}
+	}
+	return &kdb.K{Type: k.Type, Attr: kdb.NONE, Data: reflect.ValueOf(k.Data).Slice(i, j).Interface()}
+}
+
+// FromRecordReader reads all records from r into single q table
+func FromRecordReader(r array.RecordReader) (*kdb.K, error) {
+	var tables []*kdb.K
+	for r.Next() {
+		t, err := FromRecord(r.Record())
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return emptyTable(r.Schema())
+	}
+	// copy first batch too as records may be reused once released
+	res := tables[0].Data.(kdb.Table)
+	for i, c := range res.Data {
+		res.Data[i] = appendColumn(c, slice(c, 0, 0))
+	}
+	for _, t := range tables[1:] {
+		for i, c := range t.Data.(kdb.Table).Data {
+			res.Data[i] = appendColumn(res.Data[i], c)
+		}
+	}
+	return &kdb.K{Type: kdb.XT, Attr: kdb.NONE, Data: res}, nil
+}
+
+// appendColumn concatenates vectors a and b of the same type into new vector
+func appendColumn(a, b *kdb.K) *kdb.K {
+	if s, ok := a.Data.(string); ok {
+		return &kdb.K{Type: a.Type, Attr: kdb.NONE, Data: s + b.Data.(string)}
+	}
+	va, vb := reflect.ValueOf(a.Data), reflect.ValueOf(b.Data)
+	v := reflect.MakeSlice(va.Type(), 0, va.Len()+vb.Len())
+	v = reflect.AppendSlice(reflect.AppendSlice(v, va), vb)
+	return &kdb.K{Type: a.Type, Attr: kdb.NONE, Data: v.Interface()}
+}
+
+// emptyTable makes table without rows matching schema
+func emptyTable(schema *arrow.Schema) (*kdb.K, error) {
+	fields := schema.Fields()
+	cols := make([]arrow.Array, len(fields))
+	for i, f := range fields {
+		cols[i] = array.MakeArrayOfNull(memory.DefaultAllocator, f.Type, 0)
+		defer cols[i].Release()
+	}
+	rec := array.NewRecord(schema, cols, 0)
+	defer rec.Release()
+	return FromRecord(rec)
+}
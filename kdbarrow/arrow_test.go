@@ -0,0 +1,145 @@
+package kdbarrow
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/sv/kdbgo"
+)
+
+var ts = time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+var arrowTable = kdb.NewTable(
+	[]string{"b", "g", "x", "h", "i", "j", "e", "f", "c", "s", "p", "m", "d", "z", "n", "u", "v", "t", "str"},
+	[]*kdb.K{
+		{Type: kdb.KB, Attr: kdb.NONE, Data: []bool{true, false, true}},
+		{Type: kdb.UU, Attr: kdb.NONE, Data: []kdb.GUID{{1}, {}, {2, 3}}},
+		{Type: kdb.KG, Attr: kdb.NONE, Data: []byte{1, 2, 3}},
+		{Type: kdb.KH, Attr: kdb.NONE, Data: []int16{1, kdb.Nh, 3}},
+		kdb.IntV([]int32{1, kdb.Ni, kdb.Wi}),
+		kdb.LongV([]int64{1, kdb.Nj, -kdb.Wj}),
+		{Type: kdb.KE, Attr: kdb.NONE, Data: []float32{1.5, 2, 3}},
+		kdb.FloatV([]float64{1.5, 2, kdb.Wf}),
+		{Type: kdb.KC, Attr: kdb.NONE, Data: "abc"},
+		kdb.SymbolV([]string{"a", "b", "a"}),
+		kdb.TimestampV([]time.Time{ts, kdb.Np, kdb.Wp}),
+		{Type: kdb.KM, Attr: kdb.NONE, Data: []kdb.Month{1, kdb.Nm, -13}},
+		kdb.DateV([]time.Time{time.Date(1960, 5, 6, 0, 0, 0, 0, time.UTC), kdb.Nd, negWd}),
+		{Type: kdb.KZ, Attr: kdb.NONE, Data: []kdb.Datetime{0.5, kdb.Wz, -1}},
+		kdb.TimespanV([]time.Duration{time.Hour, kdb.Nn, -time.Second}),
+		{Type: kdb.KU, Attr: kdb.NONE, Data: []kdb.Minute{61, kdb.Nu, 0}},
+		{Type: kdb.KV, Attr: kdb.NONE, Data: []kdb.Second{61, kdb.Nv, 0}},
+		{Type: kdb.KT, Attr: kdb.NONE, Data: []kdb.Time{1000, kdb.Nt, 86399999}},
+		kdb.NewList(&kdb.K{Type: kdb.KC, Attr: kdb.NONE, Data: "ab"}, &kdb.K{Type: kdb.KC, Attr: kdb.NONE, Data: ""}, &kdb.K{Type: kdb.KC, Attr: kdb.NONE, Data: "c"}),
+	})
+
+func TestRecordRoundtrip(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	rec, err := NewRecord(arrowTable.Data.(kdb.Table), mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Release()
+	if rec.NumRows() != 3 || rec.NumCols() != 19 {
+		t.Fatalf("expected 3x19 record, got %dx%d", rec.NumRows(), rec.NumCols())
+	}
+	nulls := map[string]int{"g": 1, "h": 1, "i": 1, "j": 1, "p": 1, "m": 1, "d": 1, "n": 1, "u": 1, "v": 1, "t": 1}
+	for i, f := range rec.Schema().Fields() {
+		if n := rec.Column(i).NullN(); n != nulls[f.Name] {
+			t.Errorf("column %s: expected %d nulls, got %d", f.Name, nulls[f.Name], n)
+		}
+	}
+	k, err := FromRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := arrowTable.Data.(kdb.Table)
+	got := k.Data.(kdb.Table)
+	for i, c := range expected.Columns {
+		if !reflect.DeepEqual(expected.Data[i], got.Data[i]) {
+			t.Errorf("column %s: expected %v, got %v", c, expected.Data[i], got.Data[i])
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s, err := Schema(arrowTable.Data.(kdb.Table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schemaTests = []struct {
+		col      string
+		expected arrow.DataType
+	}{
+		{"j", arrow.PrimitiveTypes.Int64},
+		{"g", &arrow.FixedSizeBinaryType{ByteWidth: 16}},
+		{"s", &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}},
+		{"p", arrow.FixedWidthTypes.Timestamp_ns},
+		{"d", arrow.FixedWidthTypes.Date32},
+		{"m", arrow.FixedWidthTypes.Date32},
+		{"str", arrow.BinaryTypes.String},
+	}
+	for _, tt := range schemaTests {
+		f, _ := s.FieldsByName(tt.col)
+		if len(f) != 1 || !arrow.TypeEqual(f[0].Type, tt.expected) {
+			t.Errorf("column %s: expected %v, got %v", tt.col, tt.expected, f)
+		}
+	}
+	mixed := kdb.NewTable([]string{"a"}, []*kdb.K{kdb.NewList(kdb.Long(1), kdb.Symbol("a"))})
+	if _, err := Schema(mixed.Data.(kdb.Table)); err != ErrUnsupportedColumn {
+		t.Errorf("expected ErrUnsupportedColumn, got %v", err)
+	}
+}
+
+func TestFloatNulls(t *testing.T) {
+	tbl := kdb.NewTable([]string{"f"}, []*kdb.K{kdb.FloatV([]float64{1, kdb.Nf})})
+	rec, err := NewRecord(tbl.Data.(kdb.Table), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Release()
+	if rec.Column(0).NullN() != 1 {
+		t.Errorf("expected 1 null, got %d", rec.Column(0).NullN())
+	}
+	k, err := FromRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := k.Data.(kdb.Table).Data[0].Data.([]float64); f[0] != 1 || f[1] == f[1] {
+		t.Errorf("expected 1 0n, got %v", f)
+	}
+}
+
+func TestRecordReader(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	r, err := NewRecordReader(arrowTable.Data.(kdb.Table), 2, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	k, err := FromRecordReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := arrowTable.Data.(kdb.Table)
+	got := k.Data.(kdb.Table)
+	for i, c := range expected.Columns {
+		if !reflect.DeepEqual(expected.Data[i], got.Data[i]) {
+			t.Errorf("column %s: expected %v, got %v", c, expected.Data[i], got.Data[i])
+		}
+	}
+	empty := kdb.NewTable([]string{"a"}, []*kdb.K{kdb.LongV([]int64{})})
+	r, err = NewRecordReader(empty.Data.(kdb.Table), 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	if k, err = FromRecordReader(r); err != nil || k.Len() != 0 {
+		t.Errorf("expected empty table, got %v %v", k, err)
+	}
+}
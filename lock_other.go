@@ -0,0 +1,34 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package kdb
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile creates lock file next to file at path exclusively, waiting up to SymLockTimeout for
+// other holders, and returns function removing it. Lock files older than SymLockTimeout are left by
+// writers which died and are removed.
+func lockFile(path string) (func(), error) {
+	path += ".lock"
+	deadline := time.Now().Add(SymLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > SymLockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrSymLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package kdb
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFile takes exclusive advisory lock of file at path, creating it if needed and waiting up to
+// SymLockTimeout for other holders. Lock is released by returned function or when process exits.
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(SymLockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() { f.Close() }, nil
+		}
+		if err != syscall.EWOULDBLOCK && err != syscall.EINTR {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrSymLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,78 @@
+package kdb
+
+import "bytes"
+
+// Mapped is vector file, in the mapped layout described for SplayedTable, mapped into memory.
+// Data shares the mapping and must not be used after Close.
+type Mapped struct {
+	Type int8
+	Attr Attr
+	// Data holds elements in their q representation: []int64 for enumerations,
+	// []time.Duration since 2000.01.01 for timestamps, []int32 days for dates, []byte for chars
+	// and the same slice types as in K for other vectors
+	Data interface{}
+	mem  []byte // whole mapping
+	data []byte // elements
+}
+
+// MapFile maps vector file at path into memory. Compressed files are decompressed into memory instead.
+func MapFile(path string) (*Mapped, error) {
+	mem, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := mem
+	if bytes.HasPrefix(mem, zipMagic) {
+		if b, err = uncompressFile(mem); err != nil {
+			munmap(mem)
+			return nil, err
+		}
+		munmap(mem)
+		mem = nil
+	}
+	t, attr, n, data, err := mappedVector(b)
+	if err == nil {
+		err = ErrBadFile
+		switch {
+		case t >= KB && t <= KT && t != 3 && t != KS && int64(len(data)) >= n*int64(typeSize[t]):
+			data = data[:n*int64(typeSize[t])]
+			err = nil
+		case isEnum(t) && int64(len(data)) >= n*8:
+			data = data[:n*8]
+			err = nil
+		}
+	}
+	if err != nil {
+		munmap(mem)
+		return nil, err
+	}
+	m := &Mapped{Type: t, Attr: attr, mem: mem, data: data}
+	if isEnum(t) {
+		m.Data = sliceFromBytes(KJ, data)
+	} else {
+		m.Data = sliceFromBytes(t, data)
+	}
+	return m, nil
+}
+
+// K returns mapped vector as K, sharing memory except for chars, timestamps and dates which are converted.
+// Enumerations are against sym domain.
+func (m *Mapped) K() *K {
+	if isEnum(m.Type) {
+		return &K{m.Type, m.Attr, Enum{"sym", m.Data.([]int64)}}
+	}
+	switch m.Type {
+	case KC:
+		return &K{m.Type, m.Attr, string(m.Data.([]byte))}
+	case KP, KD:
+		return vectorFromBytes(m.Type, m.Attr, m.data)
+	}
+	return &K{m.Type, m.Attr, m.Data}
+}
+
+// Close unmaps file
+func (m *Mapped) Close() error {
+	mem := m.mem
+	m.mem, m.data, m.Data = nil, nil, nil
+	return munmap(mem)
+}
@@ -0,0 +1,14 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package kdb
+
+import "os"
+
+// mmapFile reads whole file on platforms without mmap support
+func mmapFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func munmap(b []byte) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package kdb
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMapFile(t *testing.T) {
+	dir := t.TempDir()
+	var mapTests = []struct {
+		content  []byte
+		data     interface{}
+		expected *K
+	}{
+		{mappedFile(KJ, SORTED, 3, []int64{1, 2, 3}), []int64{1, 2, 3}, &K{KJ, SORTED, []int64{1, 2, 3}}},
+		{mappedFile(KF, NONE, 2, []float64{1.5, Nf}), []float64{1.5, Nf}, nil},
+		{mappedFile(KC, NONE, 2, []byte("ab")), []byte("ab"), &K{KC, NONE, "ab"}},
+		{mappedFile(KP, NONE, 1, []int64{1}), []time.Duration{1}, TimestampV([]time.Time{qEpoch.Add(1)})},
+		{mappedFile(KD, NONE, 1, []int32{1}), []int32{1}, DateV([]time.Time{qEpoch.AddDate(0, 0, 1)})},
+		{mappedFile(KE0, PARTED, 2, []int64{0, 1}), []int64{0, 1}, &K{KE0, PARTED, Enum{"sym", []int64{0, 1}}}},
+		{zipBlocks(t, ZipGzip, 12, mappedFile(KI, NONE, 2, []int32{1, 2})), []int32{1, 2}, IntV([]int32{1, 2})},
+		{mappedFile(KJ, NONE, 0, []int64{}), []int64{}, LongV([]int64{})},
+	}
+	for i, tt := range mapTests {
+		path := filepath.Join(dir, "f")
+		writeTestFile(t, path, tt.content)
+		m, err := MapFile(path)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if reflect.TypeOf(m.Data) != reflect.TypeOf(tt.data) || reflect.ValueOf(m.Data).Len() != reflect.ValueOf(tt.data).Len() {
+			t.Errorf("%d: expected %v, got %v", i, tt.data, m.Data)
+		}
+		if tt.expected != nil && !reflect.DeepEqual(m.K(), tt.expected) {
+			t.Errorf("%d: expected %v, got %v", i, tt.expected, m.K())
+		}
+		if err := m.Close(); err != nil {
+			t.Errorf("%d: Close: %v", i, err)
+		}
+		if err := m.Close(); err != nil {
+			t.Errorf("%d: second Close: %v", i, err)
+		}
+	}
+	for i, b := range [][]byte{
+		nil,
+		mappedFile(KJ, NONE, 3, []int64{1}),
+		mappedFile(KS, NONE, 0, []byte{}),
+		{0xFF, 0x01, 0xf9, 1, 0, 0, 0, 0, 0, 0, 0},
+	} {
+		path := filepath.Join(dir, "bad")
+		writeTestFile(t, path, b)
+		if m, err := MapFile(path); err == nil {
+			t.Errorf("%d: expected error, got %v", i, m.Data)
+			m.Close()
+		}
+	}
+}
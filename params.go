@@ -0,0 +1,104 @@
+package kdb
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxParams is the maximum number of q lambda parameters
+const maxParams = 8
+
+// ErrParamCount is returned when placeholders don't match supplied arguments
+var ErrParamCount = errors.New("placeholders don't match arguments")
+
+// Params turns template with placeholders $1..$8 into lambda call.
+// It returns lambda text taking one parameter per argument and arguments converted with FromGo,
+// so that values are sent as data and never spliced into query text.
+//
+// $N followed by another digit or preceded directly by operand, e.g. `long$1 or x$1, is a cast
+// and left as is. Placeholders inside string literals are ignored.
+//
+// Template with arguments runs as body of the lambda, so assignment x:$1 creates local variable
+// which is gone after the call, use x::$1 to set global. Template without arguments is returned as is.
+func Params(template string, args ...interface{}) (string, []*K, error) {
+	if len(args) > maxParams {
+		return "", nil, ErrParamCount
+	}
+	kargs := make([]*K, len(args))
+	for i, a := range args {
+		k, err := FromGo(a)
+		if err != nil {
+			return "", nil, err
+		}
+		kargs[i] = k
+	}
+	var body strings.Builder
+	used := make([]bool, len(args))
+	instr := false
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case instr:
+			if c == '\\' && i+1 < len(template) {
+				body.WriteByte(c)
+				i++
+				c = template[i]
+			} else if c == '"' {
+				instr = false
+			}
+		case c == '"':
+			instr = true
+		case c == '$' && isPlaceholder(template, i):
+			n := int(template[i+1] - '0')
+			if n > len(args) {
+				return "", nil, ErrParamCount
+			}
+			used[n-1] = true
+			body.WriteString(paramName(n))
+			i++
+			continue
+		}
+		body.WriteByte(c)
+	}
+	for _, u := range used {
+		if !u {
+			return "", nil, ErrParamCount
+		}
+	}
+	if len(args) == 0 {
+		return body.String(), nil, nil
+	}
+	names := make([]string, len(args))
+	for i := range names {
+		names[i] = paramName(i + 1)
+	}
+	return "{[" + strings.Join(names, ";") + "] " + body.String() + "}", kargs, nil
+}
+
+// CallParams performs synchronous call of template with placeholders $1..$8 substituted by args
+func (c *KDBConn) CallParams(template string, args ...interface{}) (*K, error) {
+	cmd, kargs, err := Params(template, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Call(cmd, kargs...)
+}
+
+func isPlaceholder(s string, i int) bool {
+	if i+1 >= len(s) || s[i+1] < '1' || s[i+1] > '0'+maxParams {
+		return false
+	}
+	if i+2 < len(s) && (isIdentChar(s[i+2]) || s[i+2] == '.') {
+		return false
+	}
+	return i == 0 || !(isIdentChar(s[i-1]) || strings.IndexByte("\"`)]}", s[i-1]) >= 0)
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+func paramName(n int) string {
+	return "kdbgoArg" + strconv.Itoa(n)
+}
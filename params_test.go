@@ -0,0 +1,62 @@
+package kdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	var paramsTests = []struct {
+		template string
+		args     []interface{}
+		cmd      string
+		kargs    []*K
+	}{
+		{"select from trade where sym=$1, size>$2", []interface{}{"IBM", 100},
+			"{[kdbgoArg1;kdbgoArg2] select from trade where sym=kdbgoArg1, size>kdbgoArg2}",
+			[]*K{Symbol("IBM"), Long(100)}},
+		{"$1 in $1,`a", []interface{}{Symbol("x")},
+			"{[kdbgoArg1] kdbgoArg1 in kdbgoArg1,`a}", []*K{Symbol("x")}},
+		{"`long$1.5+\"$1\\\"$1\"+x$1+$1", []interface{}{int32(2)},
+			"{[kdbgoArg1] `long$1.5+\"$1\\\"$1\"+x$1+kdbgoArg1}", []*K{Int(2)}},
+		{"til 10", nil, "til 10", nil},
+		{"count $2;$1", []interface{}{[]string{"a"}, []float64{1}},
+			"{[kdbgoArg1;kdbgoArg2] count kdbgoArg2;kdbgoArg1}", []*K{SymbolV([]string{"a"}), FloatV([]float64{1})}},
+		{"x:$1", []interface{}{1}, "{[kdbgoArg1] x:kdbgoArg1}", []*K{Long(1)}},
+		{"x::$1", []interface{}{1}, "{[kdbgoArg1] x::kdbgoArg1}", []*K{Long(1)}},
+		{"x:1", nil, "x:1", nil},
+	}
+	for _, tt := range paramsTests {
+		cmd, kargs, err := Params(tt.template, tt.args...)
+		if err != nil {
+			t.Errorf("Params(%q): %v", tt.template, err)
+			continue
+		}
+		if cmd != tt.cmd {
+			t.Errorf("Params(%q): expected %q, got %q", tt.template, tt.cmd, cmd)
+		}
+		if !reflect.DeepEqual(kargs, tt.kargs) {
+			t.Errorf("Params(%q): expected args %v, got %v", tt.template, tt.kargs, kargs)
+		}
+	}
+}
+
+func TestParamsErrors(t *testing.T) {
+	var errorTests = []struct {
+		template string
+		args     []interface{}
+	}{
+		{"$1+$2", []interface{}{1}},
+		{"$1", []interface{}{1, 2}},
+		{"til 10", []interface{}{1}},
+		{"$1", []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}
+	for _, tt := range errorTests {
+		if _, _, err := Params(tt.template, tt.args...); err != ErrParamCount {
+			t.Errorf("Params(%q): expected ErrParamCount, got %v", tt.template, err)
+		}
+	}
+	if _, _, err := Params("$1", struct{}{}); err == nil {
+		t.Error("expected error for unsupported argument")
+	}
+}
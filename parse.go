@@ -0,0 +1,773 @@
+package kdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError describes position and reason of ParseLiteral failure
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return "parse error at " + strconv.Itoa(e.Pos) + ": " + e.Msg
+}
+
+// ParseLiteral parses q literal as produced by FormatLiteral or typed in q console, e.g.
+// `a`b!1 2, ([] sym:`a`b; px:1.0 2.0) or 2020.01.01D00:00:00.
+// It understands atoms and vectors of all basic types with type suffixes, nulls and infinities,
+// strings, general lists, enlist, attributes, dicts, tables, keyed tables and lambdas.
+// General lists of atoms of the same type are collapsed into vectors as in q.
+func ParseLiteral(s string) (*K, error) {
+	p := &parser{s: s}
+	k, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return k, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ParseError{p.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// expr parses term optionally followed by !expr, q evaluates right to left
+func (p *parser) expr() (*K, error) {
+	p.skipSpace()
+	for _, a := range []Attr{SORTED, UNIQUE, PARTED, GROUPED} {
+		if p.consume(attrPrint[a]) {
+			k, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return applyAttr(k, a), nil
+		}
+	}
+	if p.consume(",") {
+		k, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return enlist(k), nil
+	}
+	if p.consume("enlist ") {
+		k, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return enlist(k), nil
+	}
+	key, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume("!") {
+		return key, nil
+	}
+	value, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if key.Type == XT && value.Type == XT {
+		return NewDict(key, value), nil
+	}
+	if !isList(key) || !isList(value) || key.Len() != value.Len() {
+		return nil, p.errorf("length")
+	}
+	return NewDict(key, value), nil
+}
+
+func applyAttr(k *K, a Attr) *K {
+	if k.Type == XD && a == SORTED {
+		return &K{SD, NONE, k.Data}
+	}
+	k.Attr = a
+	return k
+}
+
+func enlist(k *K) *K {
+	if k.Type < K0 && k.Type >= -KT {
+		if k.Type == -KC {
+			return &K{KC, NONE, string([]byte{k.Data.(byte)})}
+		}
+		v := emptyVector(-k.Type)
+		return &K{-k.Type, NONE, appendValue(v, k.Data)}
+	}
+	return NewList(k)
+}
+
+func (p *parser) term() (*K, error) {
+	p.skipSpace()
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end")
+	case p.consume("::"):
+		return Null(KFUNCUP), nil
+	case c == '(':
+		return p.list()
+	case c == '"':
+		str, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		if len(str) == 1 {
+			return &K{-KC, NONE, str[0]}, nil
+		}
+		return &K{KC, NONE, str}, nil
+	case c == '`':
+		return p.symbols()
+	case c == '{':
+		return p.lambda()
+	case c >= '0' && c <= '9' || c == '-' || c == '.':
+		return p.vector()
+	}
+	return nil, p.errorf("unexpected %q", c)
+}
+
+// list parses general list, table or keyed table
+func (p *parser) list() (*K, error) {
+	p.pos++ // (
+	p.skipSpace()
+	if p.peek() == '[' {
+		p.pos++
+		key, err := p.columns(']')
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.columns(')')
+		if err != nil {
+			return nil, err
+		}
+		if len(key.Data.(Table).Columns) == 0 {
+			return value, nil
+		}
+		return NewDict(key, value), nil
+	}
+	var items []*K
+	for {
+		p.skipSpace()
+		if p.consume(")") {
+			break
+		}
+		if len(items) > 0 {
+			if err := p.expect(';'); err != nil {
+				return nil, err
+			}
+		}
+		item, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return collapse(items), nil
+}
+
+// collapse turns list of atoms of the same type into vector
+func collapse(items []*K) *K {
+	if len(items) == 0 || items[0].Type >= K0 || items[0].Type < -KT {
+		return NewList(items...)
+	}
+	t := items[0].Type
+	for _, item := range items {
+		if item.Type != t {
+			return NewList(items...)
+		}
+	}
+	if t == -KC {
+		b := make([]byte, len(items))
+		for i, item := range items {
+			b[i] = item.Data.(byte)
+		}
+		return &K{KC, NONE, string(b)}
+	}
+	v := emptyVector(-t)
+	for _, item := range items {
+		v = appendValue(v, item.Data)
+	}
+	return &K{-t, NONE, v}
+}
+
+// columns parses table columns name:value;... up to closing bracket
+func (p *parser) columns(end byte) (*K, error) {
+	var names []string
+	var data []*K
+	for {
+		p.skipSpace()
+		if p.consume(string(end)) {
+			break
+		}
+		if len(names) > 0 {
+			if err := p.expect(';'); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+		}
+		start := p.pos
+		for p.pos < len(p.s) && (isIdentChar(p.s[p.pos]) || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		if start == p.pos {
+			return nil, p.errorf("expected column name")
+		}
+		name := p.s[start:p.pos]
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		col, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		data = append(data, col)
+	}
+	n := -1
+	for i, col := range data {
+		if col.Type < K0 {
+			data[i] = enlist(col)
+		}
+		if !isList(data[i]) || n >= 0 && data[i].Len() != n {
+			return nil, p.errorf("length")
+		}
+		n = data[i].Len()
+	}
+	return NewTable(names, data), nil
+}
+
+// str parses q string literal
+func (p *parser) str() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.s[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(e)
+			default:
+				if p.pos+1 < len(p.s) {
+					if v, err := strconv.ParseUint(p.s[p.pos-1:p.pos+2], 8, 8); err == nil {
+						b.WriteByte(byte(v))
+						p.pos += 2
+						continue
+					}
+				}
+				return "", p.errorf("bad escape")
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// symbols parses `a, `a`b, `$"a b", `$("a";"b") and `type$()
+func (p *parser) symbols() (*K, error) {
+	if p.consume("`$") {
+		if p.peek() == '"' {
+			str, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			return Symbol(str), nil
+		}
+		if p.peek() != '(' {
+			return nil, p.errorf("expected strings")
+		}
+		list, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		switch list.Type {
+		case KC:
+			return SymbolV([]string{list.Data.(string)}), nil
+		case -KC:
+			return SymbolV([]string{string(list.Data.(byte))}), nil
+		case K0:
+			syms := make([]string, list.Len())
+			for i, item := range list.Data.([]*K) {
+				switch item.Type {
+				case KC:
+					syms[i] = item.Data.(string)
+				case -KC:
+					syms[i] = string(item.Data.(byte))
+				default:
+					return nil, p.errorf("expected strings")
+				}
+			}
+			return SymbolV(syms), nil
+		}
+		return nil, p.errorf("expected strings")
+	}
+	var syms []string
+	for p.peek() == '`' {
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.s) && plainSymbol(p.s[p.pos:p.pos+1]) {
+			p.pos++
+		}
+		syms = append(syms, p.s[start:p.pos])
+		if p.consume("$()") {
+			if len(syms) != 1 {
+				return nil, p.errorf("unexpected cast")
+			}
+			for t, name := range typeNames {
+				if name != "" && name == syms[0] {
+					if int8(t) == KC {
+						return &K{KC, NONE, ""}, nil
+					}
+					return &K{int8(t), NONE, emptyVector(int8(t))}, nil
+				}
+			}
+			return nil, p.errorf("unknown type %q", syms[0])
+		}
+	}
+	if len(syms) == 1 {
+		return Symbol(syms[0]), nil
+	}
+	return SymbolV(syms), nil
+}
+
+// lambda parses {...} keeping its text as function body
+func (p *parser) lambda() (*K, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '"':
+			if _, err := p.str(); err != nil {
+				return nil, err
+			}
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return NewFunc("", p.s[start:p.pos]), nil
+			}
+		}
+		p.pos++
+	}
+	return nil, p.errorf("unterminated lambda")
+}
+
+// vector parses space separated numeric or temporal tokens with optional type suffix on the last one
+func (p *parser) vector() (*K, error) {
+	start := p.pos
+	var tokens []string
+	for {
+		tstart := p.pos
+		for p.pos < len(p.s) && isTokenChar(p.s[p.pos]) {
+			p.pos++
+		}
+		tokens = append(tokens, p.s[tstart:p.pos])
+		// vector continues if single space is followed by another token
+		if p.pos+1 < len(p.s) && p.s[p.pos] == ' ' && startsToken(p.s[p.pos+1:]) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	k, err := parseTokens(tokens)
+	if err != nil {
+		return nil, &ParseError{start, err.Error()}
+	}
+	return k, nil
+}
+
+func isTokenChar(c byte) bool {
+	return isIdentChar(c) || c == '.' || c == ':' || c == '-'
+}
+
+func startsToken(s string) bool {
+	c := s[0]
+	if c >= '0' && c <= '9' {
+		return true
+	}
+	return (c == '-' || c == '.') && len(s) > 1 && s[1] >= '0' && s[1] <= '9'
+}
+
+func parseTokens(tokens []string) (*K, error) {
+	last := tokens[len(tokens)-1]
+	if len(tokens) == 1 {
+		switch {
+		case strings.HasPrefix(last, "0x"):
+			b, err := hex.DecodeString(last[2:])
+			if err != nil {
+				return nil, err
+			}
+			if len(b) == 1 && len(last) == 4 {
+				return &K{-KG, NONE, b[0]}, nil
+			}
+			return &K{KG, NONE, b}, nil
+		case len(last) > 1 && last[len(last)-1] == 'b' && strings.Trim(last[:len(last)-1], "01") == "":
+			bits := last[:len(last)-1]
+			if len(bits) == 1 {
+				return &K{-KB, NONE, bits == "1"}, nil
+			}
+			v := make([]bool, len(bits))
+			for i := range bits {
+				v[i] = bits[i] == '1'
+			}
+			return &K{KB, NONE, v}, nil
+		}
+	}
+	var t int8
+	bodies := append([]string{}, tokens...)
+	if isGUID(last) || last == "0Ng" {
+		t = -UU
+		bodies[len(bodies)-1] = strings.TrimSuffix(last, "g")
+	} else if c := last[len(last)-1]; strings.IndexByte("hijefpmdznuvt", c) >= 0 && !isFloatSpecial(last) {
+		t = -suffixType(c)
+		bodies[len(bodies)-1] = last[:len(last)-1]
+	} else {
+		t = inferType(tokens)
+	}
+	if len(tokens) == 1 {
+		v, err := parseAtom(t, bodies[0])
+		if err != nil {
+			return nil, err
+		}
+		return &K{t, NONE, v}, nil
+	}
+	v := emptyVector(-t)
+	for _, body := range bodies {
+		x, err := parseAtom(t, body)
+		if err != nil {
+			return nil, err
+		}
+		v = appendValue(v, x)
+	}
+	return &K{-t, NONE, v}, nil
+}
+
+func isFloatSpecial(s string) bool {
+	return s == "0n" || s == "0w" || s == "-0w"
+}
+
+func isGUID(s string) bool {
+	return len(s) == 36 && s[8] == '-' && s[13] == '-' && s[18] == '-' && s[23] == '-'
+}
+
+func suffixType(c byte) int8 {
+	return map[byte]int8{'h': KH, 'i': KI, 'j': KJ, 'e': KE, 'f': KF, 'p': KP, 'm': KM,
+		'd': KD, 'z': KZ, 'n': KN, 'u': KU, 'v': KV, 't': KT}[c]
+}
+
+// inferType returns atom type of tokens without suffix
+func inferType(tokens []string) int8 {
+	var t int8
+	for _, tok := range tokens {
+		var tt int8
+		switch {
+		case nullOrInf(tok):
+			continue
+		case isGUID(tok):
+			tt = -UU
+		case strings.Contains(tok, "D"):
+			if strings.Count(tok[:strings.Index(tok, "D")], ".") == 2 {
+				tt = -KP
+			} else {
+				tt = -KN
+			}
+		case strings.Contains(tok, "T"):
+			tt = -KZ
+		case strings.Count(tok, ":") == 1:
+			tt = -KU
+		case strings.Count(tok, ":") == 2 && strings.Contains(tok, "."):
+			tt = -KT
+		case strings.Count(tok, ":") == 2:
+			tt = -KV
+		case strings.Count(tok, ".") == 2:
+			tt = -KD
+		case isFloatSpecial(tok) || strings.ContainsAny(tok, ".e"):
+			tt = -KF
+		default:
+			tt = -KJ
+		}
+		// longs mixed with floats are floats
+		if t == 0 || t == -KJ && tt == -KF {
+			t = tt
+		}
+	}
+	if t == 0 {
+		return -KJ
+	}
+	return t
+}
+
+func parseAtom(t int8, s string) (interface{}, error) {
+	null, inf, neg := s == "0N", s == "0W", s == "-0W"
+	if t == -KF || t == -KE || t == -KZ {
+		null = null || s == "0n"
+		inf = inf || s == "0w"
+		neg = neg || s == "-0w"
+	}
+	switch t {
+	case -UU:
+		if null {
+			return GUID{}, nil
+		}
+		return ParseGUID(s)
+	case -KH:
+		switch {
+		case null:
+			return Nh, nil
+		case inf:
+			return Wh, nil
+		case neg:
+			return -Wh, nil
+		}
+		v, err := strconv.ParseInt(s, 10, 16)
+		return int16(v), err
+	case -KI:
+		v, err := parseInt(s, int64(Ni), int64(Wi), 32)
+		return int32(v), err
+	case -KJ:
+		return parseInt(s, Nj, Wj, 64)
+	case -KE:
+		switch {
+		case null:
+			return Ne, nil
+		case inf:
+			return We, nil
+		case neg:
+			return -We, nil
+		}
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case -KF:
+		switch {
+		case null:
+			return Nf, nil
+		case inf:
+			return Wf, nil
+		case neg:
+			return -Wf, nil
+		}
+		return strconv.ParseFloat(s, 64)
+	case -KZ:
+		switch {
+		case null:
+			return Nz, nil
+		case inf:
+			return Wz, nil
+		case neg:
+			return -Wz, nil
+		}
+		d, err := time.Parse("2006.01.02T15:04:05.999999999", s)
+		if err != nil {
+			return nil, err
+		}
+		return DatetimeOf(d), nil
+	case -KP:
+		switch {
+		case null:
+			return Np, nil
+		case inf:
+			return Wp, nil
+		case neg:
+			return fromTimestamp(-Wj), nil
+		}
+		i := strings.IndexByte(s, 'D')
+		if i < 0 {
+			return nil, &ParseError{0, "bad timestamp " + s}
+		}
+		d, err := time.Parse("2006.01.02", s[:i])
+		if err != nil {
+			return nil, err
+		}
+		// time of day may be left out after D, as in 2020.01.01D
+		var tod time.Duration
+		if s[i+1:] != "" {
+			tod, err = parseTimeOfDay(s[i+1:])
+		}
+		return fromTimestamp(toTimestamp(d) + int64(tod)), err
+	case -KM:
+		v, err := parseInt(s, int64(Ni), int64(Wi), 32)
+		if err == nil || null || inf || neg {
+			return Month(v), err
+		}
+		m, err := time.Parse("2006.01", s)
+		return MonthOf(m), err
+	case -KD:
+		switch {
+		case null:
+			return Nd, nil
+		case inf:
+			return Wd, nil
+		case neg:
+			return fromDate(-Wi), nil
+		}
+		return time.Parse("2006.01.02", s)
+	case -KN:
+		v, err := parseInt(s, Nj, Wj, 64)
+		if err == nil {
+			return time.Duration(v), nil
+		}
+		sign := time.Duration(1)
+		if strings.HasPrefix(s, "-") {
+			sign, s = -1, s[1:]
+		}
+		var days int64
+		if i := strings.IndexByte(s, 'D'); i >= 0 {
+			if days, err = strconv.ParseInt(s[:i], 10, 64); err != nil {
+				return nil, err
+			}
+			s = s[i+1:]
+			if s == "" {
+				// days only, as in 1D
+				return sign * time.Duration(days) * 24 * time.Hour, nil
+			}
+		}
+		tod, err := parseTimeOfDay(s)
+		return sign * (time.Duration(days)*24*time.Hour + tod), err
+	case -KU, -KV, -KT:
+		v, err := parseInt(s, int64(Ni), int64(Wi), 32)
+		if err != nil {
+			sign := time.Duration(1)
+			if strings.HasPrefix(s, "-") {
+				sign, s = -1, s[1:]
+			}
+			var tod time.Duration
+			if tod, err = parseTimeOfDay(s); err != nil {
+				return nil, err
+			}
+			tod *= sign
+			switch t {
+			case -KU:
+				return MinuteOf(tod), nil
+			case -KV:
+				return SecondOf(tod), nil
+			}
+			return TimeOf(tod), nil
+		}
+		switch t {
+		case -KU:
+			return Minute(v), nil
+		case -KV:
+			return Second(v), nil
+		}
+		return Time(v), nil
+	}
+	return nil, &ParseError{0, "unsupported literal " + s}
+}
+
+func parseInt(s string, null, inf int64, bits int) (int64, error) {
+	switch s {
+	case "0N":
+		return null, nil
+	case "0W":
+		return inf, nil
+	case "-0W":
+		return -inf, nil
+	}
+	return strconv.ParseInt(s, 10, bits)
+}
+
+// parseTimeOfDay parses hh[:mm[:ss[.fffffffff]]]
+func parseTimeOfDay(s string) (time.Duration, error) {
+	var frac time.Duration
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits := s[i+1:]
+		if len(digits) > 9 {
+			return 0, &ParseError{0, "bad time " + s}
+		}
+		v, err := strconv.ParseInt(digits+strings.Repeat("0", 9-len(digits)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		frac = time.Duration(v)
+		s = s[:i]
+	}
+	var d time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	parts := strings.Split(s, ":")
+	if len(parts) > len(units) {
+		return 0, &ParseError{0, "bad time " + s}
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v) * units[i]
+	}
+	return d + frac, nil
+}
+
+// emptyVector returns empty slice as held by K vector of type t
+func emptyVector(t int8) interface{} {
+	return reflect.MakeSlice(validTypes[t], 0, 0).Interface()
+}
+
+func appendValue(v interface{}, x interface{}) interface{} {
+	return reflect.Append(reflect.ValueOf(v), reflect.ValueOf(x)).Interface()
+}
@@ -0,0 +1,94 @@
+package kdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLiteral(t *testing.T) {
+	var parseTests = []struct {
+		s        string
+		expected *K
+	}{
+		{"`a`b!1 2", NewDict(SymbolV([]string{"a", "b"}), LongV([]int64{1, 2}))},
+		{"([] sym:`a`b; px:1.0 2.0)", NewTable([]string{"sym", "px"}, []*K{SymbolV([]string{"a", "b"}), FloatV([]float64{1, 2})})},
+		{"2020.01.01D00:00:00", Timestamp(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{"([k:`a`b] v:1 2)", NewDict(NewTable([]string{"k"}, []*K{SymbolV([]string{"a", "b"})}),
+			NewTable([]string{"v"}, []*K{LongV([]int64{1, 2})}))},
+		{"([] a:1)", NewTable([]string{"a"}, []*K{LongV([]int64{1})})},
+		{"1 2.5", FloatV([]float64{1, 2.5})},
+		{"1 -0w", FloatV([]float64{1, -Wf})},
+		{"(1;2)", LongV([]int64{1, 2})},
+		{"(1;`a)", NewList(Long(1), Symbol("a"))},
+		{"(\"a\";\"b\")", &K{KC, NONE, "ab"}},
+		{"(\"ab\";\"c\")", NewList(&K{KC, NONE, "ab"}, &K{-KC, NONE, byte('c')})},
+		{"enlist 1", LongV([]int64{1})},
+		{"`s#1 2 3", &K{KJ, SORTED, []int64{1, 2, 3}}},
+		{"`s#`a`b!1 2", &K{SD, NONE, Dict{SymbolV([]string{"a", "b"}), LongV([]int64{1, 2})}}},
+		{"0N 2020.01.01", DateV([]time.Time{Nd, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})},
+		{"12:00 0N", &K{KU, NONE, []Minute{720, Nu}}},
+		{"-0D00:00:01", Timespan(-time.Second)},
+		{"2020.01.01D", Timestamp(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{"2020.01.01D 2020.01.02D12:00", TimestampV([]time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)})},
+		{"1D", Timespan(24 * time.Hour)},
+		{"-2D 1D00:00:01", TimespanV([]time.Duration{-48 * time.Hour, 24*time.Hour + time.Second})},
+		{"00:00:01.5n", Timespan(1500 * time.Millisecond)},
+		{"2000.01.01T12:00:00.000", &K{-KZ, NONE, Datetime(0.5)}},
+		{"0Ng", &K{-UU, NONE, GUID{}}},
+		{"8c6b8b64-6815-6084-0a3e-178401251b68", &K{-UU, NONE, testGUID}},
+		{"0x", &K{KG, NONE, []byte{}}},
+		{"::", Null(KFUNCUP)},
+		{"{[x;y] x+\"}\"}", NewFunc("", "{[x;y] x+\"}\"}")},
+		{"\"\\001\"", &K{-KC, NONE, byte(1)}},
+		{" ( 1 ; `a ) ", NewList(Long(1), Symbol("a"))},
+	}
+	for _, tt := range parseTests {
+		k, err := ParseLiteral(tt.s)
+		if err != nil {
+			t.Errorf("ParseLiteral(%q): %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(k, tt.expected) {
+			t.Errorf("ParseLiteral(%q): expected %#v, got %#v", tt.s, tt.expected, k)
+		}
+	}
+}
+
+func TestParseFormatted(t *testing.T) {
+	for _, tt := range formatTests {
+		if tt.k.Type == KERR {
+			continue
+		}
+		k, err := ParseLiteral(tt.expected)
+		if err != nil {
+			t.Errorf("ParseLiteral(%q): %v", tt.expected, err)
+			continue
+		}
+		if s := FormatLiteral(k); s != tt.expected {
+			t.Errorf("ParseLiteral(%q) formatted back as %q", tt.expected, s)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"", "(1;2", "`a`b!1", "\"abc", "1 2x", "([] a:1 2; b:1)", "`foo$()", "1 2 +", "{x"} {
+		k, err := ParseLiteral(s)
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("ParseLiteral(%q): expected ParseError, got %v, %v", s, k, err)
+		}
+	}
+}
+
+func FuzzParseLiteral(f *testing.F) {
+	for _, tt := range formatTests {
+		f.Add(tt.expected)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		k, err := ParseLiteral(s)
+		if err != nil {
+			return
+		}
+		FormatLiteral(k)
+	})
+}
@@ -0,0 +1,309 @@
+package kdb
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrNoPartitions is returned for directories without date, month or int partitions
+var ErrNoPartitions = errors.New("no partitions found")
+
+// ErrNoTable is returned when table isn't present in database
+var ErrNoTable = errors.New("no such table")
+
+// ErrPartitionType is returned for partition range bounds of other type than partitions
+var ErrPartitionType = errors.New("partition bound type doesn't match database")
+
+// partitionNames maps partition type to name of the virtual column holding partition value
+var partitionNames = map[int8]string{KD: "date", KM: "month", KI: "int"}
+
+// Partition is single partition of partitioned database
+type Partition struct {
+	Value *K     // date, month or int atom
+	Dir   string // partition directory
+	key   int64
+}
+
+// Database is partitioned kdb+ database, with partitions in Dir or in segments listed in par.txt,
+// and enumerations shared through sym file in Dir
+type Database struct {
+	Dir        string
+	Segments   []string    // directories holding partitions
+	Type       int8        // type of partitions, KD, KM or KI
+	Partitions []Partition // partitions in ascending order
+	Tables     []string    // tables in the last partition
+	SymFile    string
+	sym        *SymDomain
+}
+
+// OpenDatabase scans partitions of database in dir
+func OpenDatabase(dir string) (*Database, error) {
+	db := &Database{Dir: dir, SymFile: filepath.Join(dir, "sym")}
+	segments, err := readSegments(filepath.Join(dir, "par.txt"))
+	switch {
+	case os.IsNotExist(err):
+		db.Segments = []string{dir}
+	case err != nil:
+		return nil, err
+	default:
+		for _, s := range segments {
+			if !filepath.IsAbs(s) {
+				s = filepath.Join(dir, s)
+			}
+			db.Segments = append(db.Segments, s)
+		}
+	}
+	for _, s := range db.Segments {
+		entries, err := os.ReadDir(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			t, v, key := partitionValue(e.Name())
+			if t == 0 || db.Type != 0 && t != db.Type {
+				continue
+			}
+			db.Type = t
+			db.Partitions = append(db.Partitions, Partition{v, filepath.Join(s, e.Name()), key})
+		}
+	}
+	if len(db.Partitions) == 0 {
+		return nil, ErrNoPartitions
+	}
+	sort.SliceStable(db.Partitions, func(i, j int) bool { return db.Partitions[i].key < db.Partitions[j].key })
+	entries, err := os.ReadDir(db.Partitions[len(db.Partitions)-1].Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(db.Partitions[len(db.Partitions)-1].Dir, e.Name(), ".d")); err == nil {
+			db.Tables = append(db.Tables, e.Name())
+		}
+	}
+	return db, nil
+}
+
+// readSegments reads non-empty lines of par.txt
+func readSegments(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var segments []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if s := strings.TrimSpace(sc.Text()); s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments, sc.Err()
+}
+
+// partitionValue parses partition directory name, returning zero type for other directories
+func partitionValue(name string) (int8, *K, int64) {
+	var t int8
+	switch {
+	case len(name) == 10 && name[4] == '.' && name[7] == '.':
+		t = KD
+	case len(name) == 7 && name[4] == '.':
+		t = KM
+	case name != "" && strings.Trim(name, "0123456789") == "":
+		t = KI
+	default:
+		return 0, nil, 0
+	}
+	v, err := parseAtom(-t, name)
+	if err != nil {
+		return 0, nil, 0
+	}
+	key, _, _ := rawAtom(-t, v)
+	return t, &K{-t, NONE, v}, key
+}
+
+// PartitionColumn returns name of the virtual column holding partition value, e.g. date
+func (db *Database) PartitionColumn() string {
+	return partitionNames[db.Type]
+}
+
+// Splayed opens table in partition p
+func (db *Database) Splayed(p Partition, table string) (*SplayedTable, error) {
+	s, err := openSplayed(filepath.Join(p.Dir, table), db.SymFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoTable
+		}
+		return nil, err
+	}
+	s.sym = db.sym
+	return s, nil
+}
+
+// Schema returns empty table with columns of table in the last partition, partition column first.
+// Only headers of column files are read.
+func (db *Database) Schema(table string) (*K, error) {
+	p := db.Partitions[len(db.Partitions)-1]
+	s, err := db.Splayed(p, table)
+	if err != nil {
+		return nil, err
+	}
+	columns := append([]string{db.PartitionColumn()}, s.Columns...)
+	data := make([]*K, len(columns))
+	data[0] = repeatAtom(p.Value, 0)
+	for i, c := range s.Columns {
+		if data[i+1], _, err = s.columnHeader(c); err != nil {
+			return nil, err
+		}
+	}
+	return NewTable(columns, data), nil
+}
+
+// Scan returns iterator over partitions of table between from and to inclusive, nil meaning unbounded.
+// Each chunk holds given columns, by default the partition column followed by all columns of the table.
+func (db *Database) Scan(table string, from, to *K, columns ...string) *PartitionIterator {
+	it := &PartitionIterator{db: db, table: table, columns: columns}
+	lo, hi, err := db.bounds(from, to)
+	if err != nil {
+		it.err = err
+		return it
+	}
+	for _, p := range db.Partitions {
+		if p.key >= lo && p.key <= hi {
+			it.parts = append(it.parts, p)
+		}
+	}
+	return it
+}
+
+// bounds converts range of partition values to keys
+func (db *Database) bounds(from, to *K) (int64, int64, error) {
+	lo, hi := Nj, Wj
+	for i, b := range []*K{from, to} {
+		if b == nil {
+			continue
+		}
+		if b.Type != -db.Type {
+			return 0, 0, ErrPartitionType
+		}
+		key, _, _ := rawAtom(b.Type, b.Data)
+		if i == 0 {
+			lo = key
+		} else {
+			hi = key
+		}
+	}
+	return lo, hi, nil
+}
+
+// read reads columns of table s in partition p, partition column is filled with value of p
+func (db *Database) read(s *SplayedTable, p Partition, columns []string) (*K, error) {
+	pc := db.PartitionColumn()
+	data := make([]*K, len(columns))
+	rows := -1
+	for i, c := range columns {
+		if c == pc {
+			continue
+		}
+		col, err := s.Column(c)
+		if err != nil {
+			return nil, err
+		}
+		data[i], rows = col, col.Len()
+	}
+	if rows < 0 && len(s.Columns) > 0 {
+		// only partition column requested, count rows from header of the first column
+		var err error
+		if _, rows, err = s.columnHeader(s.Columns[0]); err != nil {
+			return nil, err
+		}
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	if db.sym == nil {
+		db.sym = s.sym
+	}
+	for i, c := range columns {
+		if c == pc {
+			data[i] = repeatAtom(p.Value, rows)
+		}
+	}
+	return NewTable(columns, data), nil
+}
+
+// takeRows returns first n items of vector or list k
+func takeRows(k *K, n int) *K {
+	if s, ok := k.Data.(string); ok {
+		return &K{k.Type, k.Attr, s[:n]}
+	}
+	return &K{k.Type, k.Attr, reflect.ValueOf(k.Data).Slice(0, n).Interface()}
+}
+
+// repeatAtom returns vector with n copies of atom a
+func repeatAtom(a *K, n int) *K {
+	v := reflect.MakeSlice(validTypes[-a.Type], n, n)
+	x := reflect.ValueOf(a.Data)
+	for i := 0; i < n; i++ {
+		v.Index(i).Set(x)
+	}
+	return &K{-a.Type, NONE, v.Interface()}
+}
+
+// PartitionIterator yields table chunks partition by partition
+type PartitionIterator struct {
+	db        *Database
+	table     string
+	columns   []string
+	parts     []Partition
+	partition Partition
+	chunk     *K
+	err       error
+}
+
+// Next reads the next partition, it returns false when there are no more partitions or on error.
+// Partitions which don't contain the table are skipped.
+func (it *PartitionIterator) Next() bool {
+	for it.err == nil && len(it.parts) > 0 {
+		p := it.parts[0]
+		it.parts = it.parts[1:]
+		s, err := it.db.Splayed(p, it.table)
+		if err == ErrNoTable {
+			continue
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+		columns := it.columns
+		if len(columns) == 0 {
+			columns = append([]string{it.db.PartitionColumn()}, s.Columns...)
+		}
+		it.chunk, it.err = it.db.read(s, p, columns)
+		it.partition = p
+		return it.err == nil
+	}
+	return false
+}
+
+// Table returns chunk read by the last call to Next
+func (it *PartitionIterator) Table() *K {
+	return it.chunk
+}
+
+// Partition returns partition of the current chunk
+func (it *PartitionIterator) Partition() Partition {
+	return it.partition
+}
+
+// Err returns error which stopped iteration
+func (it *PartitionIterator) Err() error {
+	return it.err
+}
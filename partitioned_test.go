@@ -0,0 +1,144 @@
+package kdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTestPartition writes quote table with sym and px columns in partition directory dir
+func writeTestPartition(t *testing.T, dir string, syms []int64, px []float64) {
+	writeTestObject(t, filepath.Join(dir, "quote", ".d"), SymbolV([]string{"sym", "px"}))
+	writeTestFile(t, filepath.Join(dir, "quote", "sym"), mappedFile(KE0, PARTED, len(syms), syms))
+	writeTestFile(t, filepath.Join(dir, "quote", "px"), mappedFile(KF, NONE, len(px), px))
+}
+
+func date(y int, m time.Month, d int) *K {
+	return Date(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+}
+
+func TestOpenDatabase(t *testing.T) {
+	db := t.TempDir()
+	seg := t.TempDir()
+	writeTestObject(t, filepath.Join(db, "sym"), SymbolV([]string{"a", "b"}))
+	writeTestFile(t, filepath.Join(db, "par.txt"), []byte(seg+"\nseg2\n"))
+	writeTestPartition(t, filepath.Join(seg, "2020.01.03"), []int64{1}, []float64{3})
+	writeTestPartition(t, filepath.Join(db, "seg2", "2020.01.01"), []int64{0, 1}, []float64{1, 2})
+	writeTestPartition(t, filepath.Join(db, "seg2", "2020.01.02"), []int64{0}, []float64{1.5})
+	if err := os.MkdirAll(filepath.Join(seg, "2020.01.04"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(seg, "other"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenDatabase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Type != KD || d.PartitionColumn() != "date" || len(d.Partitions) != 4 {
+		t.Fatalf("unexpected partitions %v of type %d", d.Partitions, d.Type)
+	}
+	if !reflect.DeepEqual(d.Partitions[0].Value, date(2020, 1, 1)) {
+		t.Errorf("expected first partition 2020.01.01, got %v", d.Partitions[0].Value)
+	}
+
+	if len(d.Tables) != 0 {
+		t.Errorf("expected no tables in the last partition, got %v", d.Tables)
+	}
+	schema, err := d.Schema("quote")
+	if err == nil {
+		t.Errorf("expected error for the last partition without quote, got %v", schema)
+	}
+	d.Partitions = d.Partitions[:3]
+	schema, err = d.Schema("quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewTable([]string{"date", "sym", "px"}, []*K{DateV([]time.Time{}), &K{KS, PARTED, []string{}}, FloatV([]float64{})})
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected schema %v, got %v", expected, schema)
+	}
+
+	it := d.Scan("quote", date(2020, 1, 2), nil)
+	var chunks []*K
+	for it.Next() {
+		chunks = append(chunks, it.Table())
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	expectedChunks := []*K{
+		NewTable([]string{"date", "sym", "px"}, []*K{DateV([]time.Time{date(2020, 1, 2).Data.(time.Time)}), &K{KS, PARTED, []string{"a"}}, FloatV([]float64{1.5})}),
+		NewTable([]string{"date", "sym", "px"}, []*K{DateV([]time.Time{date(2020, 1, 3).Data.(time.Time)}), &K{KS, PARTED, []string{"b"}}, FloatV([]float64{3})}),
+	}
+	if !reflect.DeepEqual(chunks, expectedChunks) {
+		t.Errorf("expected %v, got %v", expectedChunks, chunks)
+	}
+
+	it = d.Scan("quote", nil, date(2020, 1, 1), "px", "date")
+	if !it.Next() || it.Next() {
+		t.Fatalf("expected single chunk, error %v", it.Err())
+	}
+	if it = d.Scan("quote", Long(1), nil); it.Next() || it.Err() != ErrPartitionType {
+		t.Errorf("expected ErrPartitionType, got %v", it.Err())
+	}
+}
+
+func TestDatabaseHeaders(t *testing.T) {
+	db := t.TempDir()
+	dir := writeTestSplayed(t, filepath.Join(db, "2020.01.01"))
+	// items are past the end of truncated, compressed and missing files, only headers can be read
+	writeTestFile(t, filepath.Join(dir, "px"), mappedFile(KF, NONE, 3, []float64{}))
+	writeTestFile(t, filepath.Join(dir, "time"), zipBlocks(t, ZipGzip, 12, mappedFile(KP, NONE, 3, []int64{0, 1, Nj})))
+	for _, f := range []string{"note#", "../sym"} {
+		if err := os.Remove(filepath.Join(dir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := OpenDatabase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := d.Schema("trade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewTable([]string{"date", "time", "sym", "px", "size", "note", "tag"}, []*K{
+		DateV([]time.Time{}), &K{KP, NONE, []time.Time{}}, &K{KS, PARTED, []string{}}, FloatV([]float64{}),
+		IntV([]int32{}), &K{K0, NONE, []*K{}}, &K{K0, NONE, []*K{}},
+	})
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected schema %v, got %v", expected, schema)
+	}
+	it := d.Scan("trade", nil, nil, "date")
+	if !it.Next() {
+		t.Fatal(it.Err())
+	}
+	day := date(2020, 1, 1).Data.(time.Time)
+	if expected := NewTable([]string{"date"}, []*K{DateV([]time.Time{day, day, day})}); !reflect.DeepEqual(it.Table(), expected) {
+		t.Errorf("expected %v, got %v", expected, it.Table())
+	}
+}
+
+func TestPartitionValue(t *testing.T) {
+	var partitionTests = []struct {
+		name     string
+		expected *K
+	}{
+		{"2020.01.02", date(2020, 1, 2)},
+		{"2020.02", &K{-KM, NONE, Month(241)}},
+		{"42", Int(42)},
+		{"2020.13.01", nil},
+		{"trade", nil},
+		{"", nil},
+	}
+	for _, tt := range partitionTests {
+		_, v, _ := partitionValue(tt.name)
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("partitionValue(%q): expected %v, got %v", tt.name, tt.expected, v)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package kdb
+
+import "errors"
+
+// ErrBadQuery is returned for queries that have no functional form
+var ErrBadQuery = errors.New("invalid query")
+
+const (
+	querySelect = iota
+	queryExec
+	queryUpdate
+	queryDelete
+)
+
+// Query builds functional select, exec, update and delete statements.
+// Expressions are parse trees built with Column, Value and Fn.
+type Query struct {
+	kind    int
+	table   string
+	where   []*K
+	byNames []string
+	by      []*K
+	names   []string
+	cols    []*K
+}
+
+// Select starts ?[t;c;b;a] select query on table t
+func Select(t string) *Query {
+	return &Query{kind: querySelect, table: t}
+}
+
+// Exec starts ?[t;c;b;a] exec query on table t
+func Exec(t string) *Query {
+	return &Query{kind: queryExec, table: t}
+}
+
+// Update starts ![t;c;b;a] update query on table t
+func Update(t string) *Query {
+	return &Query{kind: queryUpdate, table: t}
+}
+
+// Delete starts ![t;c;0b;a] delete query on table t
+func Delete(t string) *Query {
+	return &Query{kind: queryDelete, table: t}
+}
+
+// Where adds constraints, applied in order
+func (q *Query) Where(cond ...*K) *Query {
+	q.where = append(q.where, cond...)
+	return q
+}
+
+// By groups by columns
+func (q *Query) By(cols ...string) *Query {
+	for _, c := range cols {
+		q.ByExpr(c, Column(c))
+	}
+	return q
+}
+
+// ByExpr groups by expression e named name
+func (q *Query) ByExpr(name string, e *K) *Query {
+	q.byNames = append(q.byNames, name)
+	q.by = append(q.by, e)
+	return q
+}
+
+// Columns selects, updates or deletes columns as is
+func (q *Query) Columns(cols ...string) *Query {
+	for _, c := range cols {
+		q.Agg(c, Column(c))
+	}
+	return q
+}
+
+// Agg adds column name computed by expression e
+func (q *Query) Agg(name string, e *K) *Query {
+	q.names = append(q.names, name)
+	q.cols = append(q.cols, e)
+	return q
+}
+
+// Tree returns functional form of q as parse tree suitable for eval
+func (q *Query) Tree() (*K, error) {
+	if q.table == "" {
+		return nil, ErrBadQuery
+	}
+	var op = &K{KFUNCBP, NONE, byte(17)} // ?
+	if q.kind == queryUpdate || q.kind == queryDelete {
+		op = &K{KFUNCBP, NONE, byte(16)} // !
+	}
+	// constraints are enlisted so that eval returns them unevaluated
+	var c = NewList()
+	if len(q.where) > 0 {
+		c = NewList(NewList(q.where...))
+	}
+	var b = &K{-KB, NONE, false}
+	if len(q.by) > 0 {
+		if q.kind == queryDelete {
+			return nil, ErrBadQuery
+		}
+		b = NewDict(SymbolV(q.byNames), NewList(q.by...))
+	} else if q.kind == queryExec {
+		b = NewList()
+	}
+	var a *K
+	switch {
+	case q.kind == queryDelete:
+		if len(q.where) > 0 && len(q.names) > 0 {
+			return nil, ErrBadQuery
+		}
+		a = SymbolV(append([]string{}, q.names...))
+		if len(q.names) > 0 {
+			// enlisted so that eval yields column names rather than looking them up
+			a = NewList(a)
+		}
+	case len(q.cols) == 0:
+		a = NewList()
+	case q.kind == queryExec && len(q.cols) == 1:
+		a = NewList(q.cols[0])
+	default:
+		a = NewDict(SymbolV(q.names), NewList(q.cols...))
+	}
+	return NewList(op, Symbol(q.table), c, b, a), nil
+}
+
+// Query evaluates functional query on the server
+func (c *KDBConn) Query(q *Query) (*K, error) {
+	tree, err := q.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return c.Call("eval", tree)
+}
+
+// Column refers to column name in query expression
+func Column(name string) *K {
+	return Symbol(name)
+}
+
+// Value quotes x so that it's used in query expression as is.
+// Symbols are enlisted to distinguish them from column names.
+func Value(x *K) *K {
+	switch x.Type {
+	case -KS:
+		return SymbolV([]string{x.Data.(string)})
+	case KS, K0:
+		return NewList(x)
+	}
+	return x
+}
+
+// Fn applies q function or operator f to arguments in query expression
+func Fn(f string, args ...*K) *K {
+	fn, err := NewPrimitive(f)
+	if err != nil {
+		fn = Symbol(f)
+	}
+	return NewList(append([]*K{fn}, args...)...)
+}
@@ -0,0 +1,54 @@
+package kdb
+
+import "testing"
+
+func TestQueryTree(t *testing.T) {
+	var queryTests = []struct {
+		q        *Query
+		expected string
+	}{
+		{Select("trade"), "(?;`trade;();0b;())"},
+		{Select("trade").Columns("sym", "price"), "(?;`trade;();0b;`sym`price!(`sym;`price))"},
+		{Select("trade").
+			Where(Fn("=", Column("sym"), Value(Symbol("IBM"))), Fn(">", Column("size"), Long(100))).
+			By("sym").
+			Agg("vwap", Fn("wavg", Column("size"), Column("price"))),
+			"(?;`trade;,((=;`sym;,`IBM);(>;`size;100));(,`sym)!,`sym;(,`vwap)!,(wavg;`size;`price))"},
+		{Select("trade").Where(Fn("in", Column("sym"), Value(SymbolV([]string{"a", "b"})))),
+			"(?;`trade;,,(in;`sym;,`a`b);0b;())"},
+		{Select("trade").ByExpr("d", Fn("xbar", Long(5), Column("time"))).Agg("n", Fn("count", Column("i"))),
+			"(?;`trade;();(,`d)!,(`xbar;5;`time);(,`n)!,(`count;`i))"},
+		{Exec("trade").Columns("price"), "(?;`trade;();();,`price)"},
+		{Exec("trade").Agg("p", Fn("sum", Column("price"))), "(?;`trade;();();,(sum;`price))"},
+		{Exec("trade").Columns("sym", "price"), "(?;`trade;();();`sym`price!(`sym;`price))"},
+		{Update("trade").Agg("price", Fn("*", Float(2), Column("price"))),
+			"(!;`trade;();0b;(,`price)!,(*;2f;`price))"},
+		{Delete("trade").Where(Fn("<", Column("size"), Long(0))), "(!;`trade;,,(<;`size;0);0b;`symbol$())"},
+		{Delete("trade").Columns("size"), "(!;`trade;();0b;,,`size)"},
+	}
+	for _, tt := range queryTests {
+		tree, err := tt.q.Tree()
+		if err != nil {
+			t.Errorf("expected %s, got error %v", tt.expected, err)
+			continue
+		}
+		if s := FormatLiteral(tree); s != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, s)
+		}
+		if err := Validate(tree); err != nil {
+			t.Errorf("%s: %v", tt.expected, err)
+		}
+	}
+}
+
+func TestBadQuery(t *testing.T) {
+	for _, q := range []*Query{
+		Select(""),
+		Delete("t").Where(Fn("=", Column("a"), Long(1))).Columns("b"),
+		Delete("t").By("a"),
+	} {
+		if _, err := q.Tree(); err != ErrBadQuery {
+			t.Errorf("expected ErrBadQuery, got %v", err)
+		}
+	}
+}
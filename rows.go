@@ -0,0 +1,188 @@
+package kdb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrScanNull is returned by Rows.Scan for null cells which can't be represented in destination
+var ErrScanNull = errors.New("null can't be scanned into destination")
+
+// ErrScanType is returned by Rows.Scan for destinations which cell can't be converted to
+var ErrScanType = errors.New("unsupported scan destination")
+
+// Rows iterates over rows of table reading cells straight from column vectors
+type Rows struct {
+	tbl Table
+	n   int
+	i   int
+	err error
+}
+
+// Rows returns iterator positioned before the first row
+func (tbl Table) Rows() *Rows {
+	n := 0
+	if len(tbl.Data) > 0 {
+		n = tbl.Data[0].Len()
+	}
+	return &Rows{tbl: tbl, n: n, i: -1}
+}
+
+// Columns returns column names
+func (r *Rows) Columns() []string {
+	return r.tbl.Columns
+}
+
+// Next advances to the next row, it returns false after the last row or after Scan failed
+func (r *Rows) Next() bool {
+	if r.err != nil || r.i >= r.n {
+		return false
+	}
+	r.i++
+	return r.i < r.n
+}
+
+// Err returns error of Scan which stopped iteration
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Scan copies cells of the current row into dest, one pointer per column.
+//
+// Destination of the same Go type as column items, e.g. *int64 for long column, receives the item as is,
+// nulls included. **K and *interface{} receive atom and item respectively, sql.Scanner such as sql.NullInt64
+// is given value as by database/sql driver. Other destinations are converted to: integer columns scan into
+// any integer or float, floats into any float, symbols, chars and guids into string or []byte,
+// timestamps, dates, months and datetimes into time.Time and timespans, minutes, seconds and times into
+// time.Duration. Nulls can't be converted and fail with ErrScanNull.
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.i < 0 || r.i >= r.n {
+		return errors.New("Scan called without Next")
+	}
+	if len(dest) != len(r.tbl.Data) {
+		return fmt.Errorf("expected %d destinations, got %d", len(r.tbl.Data), len(dest))
+	}
+	for j, d := range dest {
+		if err := scanCell(d, r.tbl.Data[j], r.i); err != nil {
+			r.err = fmt.Errorf("column %s: %w", r.tbl.Columns[j], err)
+			return r.err
+		}
+	}
+	return nil
+}
+
+// scanner is the sql.Scanner interface
+type scanner interface {
+	Scan(src interface{}) error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// scanCell stores i'th item of col in dest
+func scanCell(dest interface{}, col *K, i int) error {
+	switch d := dest.(type) {
+	case **K:
+		*d = indexK(col, i)
+		return nil
+	case *interface{}:
+		*d = col.Index(i)
+		return nil
+	case scanner:
+		return d.Scan(sqlValue(col, i))
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("%w %T", ErrScanType, dest)
+	}
+	dv = dv.Elem()
+	if col.Type > K0 && col.Type <= KT {
+		if v := reflect.ValueOf(col.Data).Index(i); v.Type() == dv.Type() {
+			dv.Set(v)
+			return nil
+		}
+	}
+	v := sqlValue(col, i)
+	if v == nil {
+		return ErrScanNull
+	}
+	switch x := v.(type) {
+	case int64:
+		if isDuration(col, i) != (dv.Type() == durationType) {
+			break
+		}
+		switch dv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if dv.OverflowInt(x) {
+				return fmt.Errorf("%w: %d overflows %v", ErrScanType, x, dv.Type())
+			}
+			dv.SetInt(x)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if x < 0 || dv.OverflowUint(uint64(x)) {
+				return fmt.Errorf("%w: %d overflows %v", ErrScanType, x, dv.Type())
+			}
+			dv.SetUint(uint64(x))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			dv.SetFloat(float64(x))
+			return nil
+		}
+	case float64:
+		switch dv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dv.SetFloat(x)
+			return nil
+		}
+	case string:
+		switch {
+		case dv.Kind() == reflect.String:
+			dv.SetString(x)
+			return nil
+		case dv.Type() == reflect.TypeOf([]byte{}):
+			dv.SetBytes([]byte(x))
+			return nil
+		}
+	case []byte:
+		if dv.Type() == reflect.TypeOf([]byte{}) {
+			dv.SetBytes(append([]byte{}, x...))
+			return nil
+		}
+	case time.Time:
+		if dv.Type() == reflect.TypeOf(x) {
+			dv.Set(reflect.ValueOf(x))
+			return nil
+		}
+	case bool:
+		if dv.Kind() == reflect.Bool {
+			dv.SetBool(x)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %T for %v", ErrScanType, dest, typeName(col, i))
+}
+
+// isDuration reports whether i'th item of col is timespan, minute, second or time, which scan as nanoseconds
+func isDuration(col *K, i int) bool {
+	t := col.Type
+	if t == K0 {
+		t = -indexK(col, i).Type
+	}
+	return t >= KN && t <= KT
+}
+
+// typeName returns q name of type of i'th item of col
+func typeName(col *K, i int) string {
+	t := col.Type
+	if t == K0 {
+		t = indexK(col, i).Type
+		if t < 0 {
+			t = -t
+		}
+	}
+	if t > K0 && t <= KT {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("type %d", t)
+}
@@ -0,0 +1,150 @@
+package kdb
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRows(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tbl := NewTable([]string{"sym", "px", "time", "size"}, []*K{
+		SymbolV([]string{"a", "b"}),
+		FloatV([]float64{1.5, 2}),
+		TimestampV([]time.Time{ts, ts.Add(time.Second)}),
+		IntV([]int32{10, Ni}),
+	}).Data.(Table)
+	rows := tbl.Rows()
+	if !reflect.DeepEqual(rows.Columns(), tbl.Columns) {
+		t.Errorf("unexpected columns %v", rows.Columns())
+	}
+	var (
+		syms  []string
+		pxs   []float64
+		times []time.Time
+		sizes []sql.NullInt64
+	)
+	for rows.Next() {
+		var sym string
+		var px float64
+		var tm time.Time
+		var size sql.NullInt64
+		if err := rows.Scan(&sym, &px, &tm, &size); err != nil {
+			t.Fatal(err)
+		}
+		syms, pxs, times, sizes = append(syms, sym), append(pxs, px), append(times, tm), append(sizes, size)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(syms, []string{"a", "b"}) || !reflect.DeepEqual(pxs, []float64{1.5, 2}) ||
+		!reflect.DeepEqual(times, []time.Time{ts, ts.Add(time.Second)}) ||
+		!reflect.DeepEqual(sizes, []sql.NullInt64{{Int64: 10, Valid: true}, {}}) {
+		t.Errorf("unexpected rows %v %v %v %v", syms, pxs, times, sizes)
+	}
+	if rows.Next() {
+		t.Error("Next after the last row")
+	}
+}
+
+func TestRowsScanErrors(t *testing.T) {
+	tbl := NewTable([]string{"size"}, []*K{IntV([]int32{Ni})}).Data.(Table)
+	rows := tbl.Rows()
+	var size int64
+	if err := rows.Scan(&size); err == nil {
+		t.Error("expected error for Scan without Next")
+	}
+	rows.Next()
+	if err := rows.Scan(&size, &size); err == nil {
+		t.Error("expected error for wrong number of destinations")
+	}
+	if err := rows.Scan(&size); !errors.Is(err, ErrScanNull) {
+		t.Errorf("expected %v, got %v", ErrScanNull, err)
+	}
+	if rows.Next() || !errors.Is(rows.Err(), ErrScanNull) {
+		t.Errorf("expected iteration to stop with %v, got %v", ErrScanNull, rows.Err())
+	}
+}
+
+func TestScanCell(t *testing.T) {
+	var (
+		i8  int8
+		i32 int32
+		i64 int64
+		u16 uint16
+		u64 uint64
+		f32 float32
+		s   string
+		b   []byte
+		d   time.Duration
+		tm  time.Time
+		ok  bool
+		m   Month
+		k   *K
+		x   interface{}
+	)
+	var scanTests = []struct {
+		col      *K
+		dest     interface{}
+		expected interface{}
+		err      error
+	}{
+		{IntV([]int32{Ni}), &i32, Ni, nil},
+		{IntV([]int32{7}), &i64, int64(7), nil},
+		{LongV([]int64{300}), &i8, nil, ErrScanType},
+		{LongV([]int64{300}), &u16, uint16(300), nil},
+		{&K{KG, NONE, []byte{255}}, &u64, uint64(255), nil},
+		{LongV([]int64{-1}), &u64, nil, ErrScanType},
+		{IntV([]int32{70000}), &u16, nil, ErrScanType},
+		{LongV([]int64{3}), &f32, float32(3), nil},
+		{FloatV([]float64{1.5}), &i64, nil, ErrScanType},
+		{FloatV([]float64{Nf}), &f32, nil, ErrScanNull},
+		{&K{KC, NONE, "x"}, &s, "x", nil},
+		{SymbolV([]string{"abc"}), &b, []byte("abc"), nil},
+		{&K{UU, NONE, []GUID{{1}}}, &s, "01000000-0000-0000-0000-000000000000", nil},
+		{&K{KT, NONE, []Time{TimeOf(time.Second)}}, &d, time.Second, nil},
+		{&K{KT, NONE, []Time{TimeOf(time.Second)}}, &i64, nil, ErrScanType},
+		{TimespanV([]time.Duration{time.Minute}), &d, time.Minute, nil},
+		{&K{KM, NONE, []Month{NewMonth(2020, 2)}}, &tm, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), nil},
+		{&K{KM, NONE, []Month{NewMonth(2020, 2)}}, &m, NewMonth(2020, 2), nil},
+		{&K{KB, NONE, []bool{true}}, &ok, true, nil},
+		{&K{KB, NONE, []bool{true}}, &s, nil, ErrScanType},
+		{NewList(&K{KC, NONE, "note"}), &s, "note", nil},
+		{NewList(Timespan(time.Hour)), &d, time.Hour, nil},
+		{LongV([]int64{5}), &k, Long(5), nil},
+		{LongV([]int64{5}), &x, int64(5), nil},
+		{LongV([]int64{5}), i64, nil, ErrScanType},
+	}
+	for _, tt := range scanTests {
+		err := scanCell(tt.dest, tt.col, 0)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("scan %v into %T: expected %v, got %v", tt.col, tt.dest, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("scan %v into %T: %v", tt.col, tt.dest, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.dest).Elem().Interface(); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("scan %v into %T: expected %v, got %v", tt.col, tt.dest, tt.expected, got)
+		}
+	}
+}
+
+func BenchmarkRowsScan(b *testing.B) {
+	n := 1000
+	syms, pxs := make([]string, n), make([]float64, n)
+	tbl := NewTable([]string{"sym", "px"}, []*K{SymbolV(syms), FloatV(pxs)}).Data.(Table)
+	var sym string
+	var px float64
+	for i := 0; i < b.N; i++ {
+		rows := tbl.Rows()
+		for rows.Next() {
+			rows.Scan(&sym, &px)
+		}
+	}
+}
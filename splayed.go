@@ -0,0 +1,256 @@
+package kdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Column files of splayed tables come in two layouts:
+//
+// Serialized objects start with 0xFF 0x01 followed by the object as in IPC message,
+// same as files written by WriteToFile. The .d file and sym file use this layout.
+//
+// Mapped vectors start with 16 byte header: 0xFE 0x20, type, attribute, 4 reserved bytes
+// and int64 element count, followed by little endian elements. Enumerations (types 20..76)
+// hold int64 indices into the sym file of the database. Nested columns such as strings have
+// type 77+t, the header is followed by int64 end offsets of every item and the items themselves
+// are stored one after another in file with the same name and # suffix.
+
+// ErrBadFile is returned for files which aren't kdb+ data files
+var ErrBadFile = errors.New("invalid kdb+ file")
+
+// ErrNoColumn is returned when requested column doesn't exist in table
+var ErrNoColumn = errors.New("no such column")
+
+var (
+	serializedPrefix = []byte{0xFF, 0x01}
+	mappedPrefix     = []byte{0xFE, 0x20}
+)
+
+const mappedHeaderSize = 16
+
+// SplayedTable is table stored as directory with .d file listing columns and one file per column.
+// Columns are read from disk only when requested.
+type SplayedTable struct {
+	Dir     string   // table directory
+	Columns []string // column names in order of .d file
+	SymFile string   // file with domain of enumerated columns, sym next to Dir by default
+	sym     *SymDomain
+}
+
+// OpenSplayed reads column list of table splayed in dir
+func OpenSplayed(dir string) (*SplayedTable, error) {
+	return openSplayed(dir, filepath.Join(filepath.Dir(dir), "sym"))
+}
+
+func openSplayed(dir, symFile string) (*SplayedTable, error) {
+	d, err := readFileObject(filepath.Join(dir, ".d"))
+	if err != nil {
+		return nil, err
+	}
+	if d.Type != KS {
+		return nil, ErrBadFile
+	}
+	return &SplayedTable{Dir: dir, Columns: d.Data.([]string), SymFile: symFile}, nil
+}
+
+// Column reads single column, enumerated symbols are resolved against SymFile
+func (s *SplayedTable) Column(name string) (*K, error) {
+	if indexOf(s.Columns, name) < 0 {
+		return nil, ErrNoColumn
+	}
+	path := filepath.Join(s.Dir, name)
+	b, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if t, _, _, _, err := mappedVector(b); err == nil && t > KANYMAP && t < KNESTSYM {
+		items, err := readFile(path + "#")
+		if err != nil {
+			return nil, err
+		}
+		return nestedVector(b, items)
+	}
+	k, err := decodeFile(b)
+	if err != nil {
+		return nil, err
+	}
+	if isEnum(k.Type) {
+		if s.sym == nil {
+			if s.sym, err = LoadSymDomain(s.SymFile); err != nil {
+				return nil, err
+			}
+		}
+		// file enumerations always name their domain sym, whatever the name of SymFile
+		return k.Resolve(s.sym.Symbols)
+	}
+	return k, nil
+}
+
+// columnHeader returns empty column of the same type as column name and its length, reading only
+// header of the column file where it tells both
+func (s *SplayedTable) columnHeader(name string) (*K, int, error) {
+	if indexOf(s.Columns, name) < 0 {
+		return nil, 0, ErrNoColumn
+	}
+	b, err := readFileHeader(filepath.Join(s.Dir, name))
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		t    int8
+		attr Attr
+		n    int64
+	)
+	switch {
+	case bytes.HasPrefix(b, serializedPrefix) && len(b) >= 8:
+		t, attr, n = int8(b[2]), Attr(b[3]), int64(binary.LittleEndian.Uint32(b[4:8]))
+	case bytes.HasPrefix(b, mappedPrefix) && len(b) >= mappedHeaderSize:
+		t, attr, n = int8(b[2]), Attr(b[3]), int64(binary.LittleEndian.Uint64(b[8:16]))
+		if isEnum(t) {
+			t = KS
+		} else if t > KANYMAP && t < KNESTSYM {
+			t, attr = K0, NONE
+		}
+	}
+	if attr >= NONE && attr <= GROUPED && n >= 0 && int64(int(n)) == n {
+		switch {
+		case t == KC:
+			return &K{t, attr, ""}, int(n), nil
+		case t == K0 || t >= KB && t <= KT && t != 3:
+			return &K{t, attr, emptyVector(t)}, int(n), nil
+		}
+	}
+	// other objects don't have length in header
+	col, err := s.Column(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return takeRows(col, 0), col.Len(), nil
+}
+
+// Table reads given columns, or all of them if none are given, into table
+func (s *SplayedTable) Table(columns ...string) (*K, error) {
+	if len(columns) == 0 {
+		columns = s.Columns
+	}
+	data := make([]*K, len(columns))
+	for i, c := range columns {
+		col, err := s.Column(c)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = col
+	}
+	return NewTable(columns, data), nil
+}
+
+// readFile returns content of file at path, decompressing compressed files
+func readFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, zipMagic) {
+		return uncompressFile(b)
+	}
+	return b, nil
+}
+
+// readFileHeader returns the first bytes of file at path, enough to hold header of serialized object or
+// mapped vector. Only the first block of compressed files is decompressed.
+func readFileHeader(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := make([]byte, zipHeaderSize)
+	n, err := io.ReadFull(f, b)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if bytes.HasPrefix(b[:n], zipMagic) {
+		return uncompressFirstBlock(f, b[:n])
+	}
+	return b[:n], nil
+}
+
+func readFileObject(path string) (*K, error) {
+	b, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFile(b)
+}
+
+// decodeFile decodes serialized object or mapped vector
+func decodeFile(b []byte) (*K, error) {
+	if bytes.HasPrefix(b, serializedPrefix) {
+		var opts DecodeOptions
+		r := bufio.NewReader(bytes.NewReader(b[len(serializedPrefix):]))
+		return readData(r, binary.LittleEndian, opts.bounded(int64(len(b))), 0)
+	}
+	t, attr, n, data, err := mappedVector(b)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t >= KB && t <= KT && t != 3 && t != KS:
+		if int64(len(data)) < n*int64(typeSize[t]) {
+			return nil, ErrBadFile
+		}
+		return vectorFromBytes(t, attr, data[:n*int64(typeSize[t])]), nil
+	case isEnum(t):
+		if int64(len(data)) < n*8 {
+			return nil, ErrBadFile
+		}
+		index := make([]int64, n)
+		binary.Read(bytes.NewReader(data), binary.LittleEndian, index)
+		return &K{t, attr, Enum{"sym", index}}, nil
+	}
+	return nil, &UnsupportedTypeError{t}
+}
+
+// mappedVector parses header of mapped vector and returns its type, attribute, length and data
+func mappedVector(b []byte) (int8, Attr, int64, []byte, error) {
+	if len(b) < mappedHeaderSize || !bytes.HasPrefix(b, mappedPrefix) {
+		return 0, NONE, 0, nil, ErrBadFile
+	}
+	t, attr := int8(b[2]), Attr(b[3])
+	n := int64(binary.LittleEndian.Uint64(b[8:16]))
+	if t < 0 || attr < NONE || attr > GROUPED || n < 0 || n > int64(len(b)) {
+		return 0, NONE, 0, nil, ErrBadFile
+	}
+	return t, attr, n, b[mappedHeaderSize:], nil
+}
+
+// nestedVector builds list of vectors from offsets in mapped file b and items stored in file #
+func nestedVector(b, items []byte) (*K, error) {
+	t, _, n, data, err := mappedVector(b)
+	if err != nil {
+		return nil, err
+	}
+	t -= KANYMAP
+	if t < KB || t > KT || t == 3 || t == KS || int64(len(data)) < n*8 {
+		return nil, &UnsupportedTypeError{t + KANYMAP}
+	}
+	size := int64(typeSize[t])
+	list := make([]*K, n)
+	var start int64
+	for i := range list {
+		end := int64(binary.LittleEndian.Uint64(data[i*8:]))
+		if end < start || end > int64(len(items))/size {
+			return nil, ErrBadFile
+		}
+		// copy as items aren't aligned to element size
+		list[i] = vectorFromBytes(t, NONE, append([]byte{}, items[start*size:end*size]...))
+		start = end
+	}
+	return &K{K0, NONE, list}, nil
+}
@@ -0,0 +1,142 @@
+package kdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mappedFile returns content of mapped vector file with n elements
+func mappedFile(t int8, attr Attr, n int, data interface{}) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFE, 0x20, byte(t), byte(attr), 0, 0, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, int64(n))
+	binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func writeTestFile(t *testing.T, path string, b []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestObject(t *testing.T, path string, k *K) {
+	t.Helper()
+	buf, err := WriteToBuffer(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, buf.Bytes())
+}
+
+// writeTestSplayed writes trade table with time, sym, px, size, note and tag columns under db
+func writeTestSplayed(t *testing.T, db string) string {
+	dir := filepath.Join(db, "trade")
+	writeTestObject(t, filepath.Join(db, "sym"), SymbolV([]string{"a", "b"}))
+	writeTestObject(t, filepath.Join(dir, ".d"), SymbolV([]string{"time", "sym", "px", "size", "note", "tag"}))
+	writeTestFile(t, filepath.Join(dir, "time"), mappedFile(KP, NONE, 3, []int64{0, 1, Nj}))
+	writeTestFile(t, filepath.Join(dir, "sym"), mappedFile(KE0, PARTED, 3, []int64{0, 0, 1}))
+	writeTestFile(t, filepath.Join(dir, "px"), mappedFile(KF, NONE, 3, []float64{1.5, 2, 3}))
+	writeTestFile(t, filepath.Join(dir, "size"), mappedFile(KI, NONE, 3, []int32{1, 2, Ni}))
+	writeTestFile(t, filepath.Join(dir, "note"), mappedFile(KANYMAP+KC, NONE, 3, []int64{2, 2, 5}))
+	writeTestFile(t, filepath.Join(dir, "note#"), []byte("abcde"))
+	writeTestObject(t, filepath.Join(dir, "tag"), NewList(Long(1), Symbol("x"), &K{KC, NONE, "y"}))
+	return dir
+}
+
+func TestOpenSplayed(t *testing.T) {
+	dir := writeTestSplayed(t, t.TempDir())
+	s, err := OpenSplayed(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Columns, []string{"time", "sym", "px", "size", "note", "tag"}) {
+		t.Errorf("unexpected columns %v", s.Columns)
+	}
+	tbl, err := s.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewTable(s.Columns, []*K{
+		TimestampV([]time.Time{qEpoch, qEpoch.Add(1), Np}),
+		&K{KS, PARTED, []string{"a", "a", "b"}},
+		FloatV([]float64{1.5, 2, 3}),
+		IntV([]int32{1, 2, Ni}),
+		NewList(&K{KC, NONE, "ab"}, &K{KC, NONE, ""}, &K{KC, NONE, "cde"}),
+		NewList(Long(1), Symbol("x"), &K{KC, NONE, "y"}),
+	})
+	if !reflect.DeepEqual(tbl, expected) {
+		t.Errorf("expected %v, got %v", expected, tbl)
+	}
+	tbl, err = s.Table("px", "sym")
+	if err != nil || !reflect.DeepEqual(tbl.Data.(Table).Columns, []string{"px", "sym"}) {
+		t.Errorf("expected px and sym columns, got %v %v", tbl, err)
+	}
+	if _, err := s.Column("missing"); err != ErrNoColumn {
+		t.Errorf("expected ErrNoColumn, got %v", err)
+	}
+}
+
+func TestReadMappedFile(t *testing.T) {
+	dir := t.TempDir()
+	var fileTests = []struct {
+		content  []byte
+		expected *K
+		err      bool
+	}{
+		{mappedFile(KJ, SORTED, 2, []int64{1, 2}), &K{KJ, SORTED, []int64{1, 2}}, false},
+		{mappedFile(KC, NONE, 3, []byte("abc")), &K{KC, NONE, "abc"}, false},
+		{mappedFile(KD, NONE, 1, []int32{1}), DateV([]time.Time{qEpoch.AddDate(0, 0, 1)}), false},
+		{mappedFile(KE0+1, NONE, 2, []int64{1, 0}), Enumeration(KE0+1, "sym", []int64{1, 0}), false},
+		{mappedFile(KJ, NONE, 3, []int64{1, 2}), nil, true},
+		{mappedFile(KS, NONE, 0, []byte{}), nil, true},
+		{[]byte{0xFE, 0x20, 7}, nil, true},
+		{[]byte("text"), nil, true},
+	}
+	for i, tt := range fileTests {
+		path := filepath.Join(dir, "f")
+		writeTestFile(t, path, tt.content)
+		k, err := ReadFromFile(path)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%d: expected error, got %v", i, k)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(k, tt.expected) {
+			t.Errorf("%d: expected %v, got %v %v", i, tt.expected, k, err)
+		}
+	}
+}
+
+func TestOpenQSplayed(t *testing.T) {
+	s, err := OpenSplayed(qFixture(t, "splay", "trade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := s.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	expected := NewTable([]string{"time", "sym", "px", "size", "note", "tag"}, []*K{
+		TimestampV([]time.Time{ts, ts.Add(time.Second), Np}),
+		SymbolV([]string{"a", "a", "b"}),
+		FloatV([]float64{1.5, 2, 3}),
+		IntV([]int32{1, 2, Ni}),
+		NewList(&K{KC, NONE, ""}, &K{KC, NONE, "ab"}, &K{KC, NONE, "cde"}),
+		NewList(Long(1), Symbol("x"), &K{KC, NONE, "y"}),
+	})
+	if !reflect.DeepEqual(k, expected) {
+		t.Errorf("expected %v, got %v", expected, k)
+	}
+}
@@ -0,0 +1,287 @@
+package kdb
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serveSQL starts server answering every request with handle and returns DSN to connect to it
+func serveSQL(t *testing.T, handle func(req *K) (*K, error)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if _, err := r.ReadBytes(0); err != nil {
+					return
+				}
+				conn.Write([]byte{3})
+				for {
+					req, _, err := Decode(r)
+					if err != nil {
+						return
+					}
+					res, err := handle(req)
+					if err != nil {
+						res = Error(err)
+					}
+					if Encode(conn, RESPONSE, res) != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return "kdb://user:pass@" + ln.Addr().String()
+}
+
+func TestSQLOpenConnector(t *testing.T) {
+	var dsnTests = []struct {
+		dsn string
+		c   *connector
+	}{
+		{"kdb://localhost:5000", &connector{host: "localhost", port: 5000}},
+		{"kdb://u:p@host:5000?tls=true&timeout=2s", &connector{host: "host", port: 5000, auth: "u:p", tls: true, timeout: 2 * time.Second}},
+		{"kdb://u@host:5000", &connector{host: "host", port: 5000, auth: "u"}},
+		{"postgres://host:5000", nil},
+		{"kdb://host", nil},
+		{"kdb://host:5000?tls=maybe", nil},
+		{"kdb://host:5000?timeout=1", nil},
+		{"kdb://host:5000?maxmsgsize=100&maxveclen=10&maxdepth=3&maxsymlen=8", &connector{host: "host", port: 5000,
+			opts: DecodeOptions{MaxMsgSize: 100, MaxVectorLen: 10, MaxDepth: 3, MaxSymbolLen: 8}}},
+		{"kdb://host:5000?maxmsgsize=-1", nil},
+		{"kdb://host:5000?maxdepth=x", nil},
+	}
+	d := &Driver{}
+	for _, tt := range dsnTests {
+		c, err := d.OpenConnector(tt.dsn)
+		if tt.c == nil {
+			if err == nil {
+				t.Errorf("OpenConnector(%q): expected error", tt.dsn)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("OpenConnector(%q): %v", tt.dsn, err)
+			continue
+		}
+		tt.c.driver = d
+		if !reflect.DeepEqual(c, tt.c) {
+			t.Errorf("OpenConnector(%q): expected %+v, got %+v", tt.dsn, tt.c, c)
+		}
+	}
+}
+
+func TestSQLQuery(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	trades := NewTable([]string{"sym", "px", "size", "time", "note"}, []*K{
+		SymbolV([]string{"a", ""}),
+		FloatV([]float64{1.5, math.NaN()}),
+		LongV([]int64{10, Nj}),
+		TimestampV([]time.Time{ts, Np}),
+		NewList(&K{KC, NONE, "first"}, Long(2)),
+	})
+	var got *K
+	dsn := serveSQL(t, func(req *K) (*K, error) {
+		got = req
+		return trades, nil
+	})
+	db, err := sql.Open("kdb", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("{[s;n] select from trade where sym=s, size>n}", "a", sql.NullInt64{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	expected := NewList(&K{KC, NONE, "{[s;n] select from trade where sym=s, size>n}"}, Symbol("a"), Null(-KJ))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected request %v, got %v", expected, got)
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, ct := range types {
+		names = append(names, ct.DatabaseTypeName())
+	}
+	if !reflect.DeepEqual(names, []string{"symbol", "float", "long", "timestamp", ""}) {
+		t.Errorf("unexpected column types %v", names)
+	}
+	if st := types[3].ScanType(); st != reflect.TypeOf(sql.NullTime{}) {
+		t.Errorf("unexpected scan type %v", st)
+	}
+	type row struct {
+		sym  sql.NullString
+		px   sql.NullFloat64
+		size sql.NullInt64
+		time sql.NullTime
+		note interface{}
+	}
+	var res []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.sym, &r.px, &r.size, &r.time, &r.note); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expectedRows := []row{
+		{sql.NullString{String: "a", Valid: true}, sql.NullFloat64{Float64: 1.5, Valid: true},
+			sql.NullInt64{Int64: 10, Valid: true}, sql.NullTime{Time: ts, Valid: true}, "first"},
+		{note: int64(2)},
+	}
+	if !reflect.DeepEqual(res, expectedRows) {
+		t.Errorf("expected %v, got %v", expectedRows, res)
+	}
+}
+
+func TestSQLResults(t *testing.T) {
+	keyed := NewDict(NewTable([]string{"sym"}, []*K{SymbolV([]string{"a"})}),
+		NewTable([]string{"px"}, []*K{FloatV([]float64{2})}))
+	dsn := serveSQL(t, func(req *K) (*K, error) {
+		switch req.Data.(string) {
+		case "keyed":
+			return keyed, nil
+		case "atom":
+			return Long(1), nil
+		}
+		return nil, errors.New("type")
+	})
+	db, err := sql.Open("kdb", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	var sym string
+	var px float64
+	if err := db.QueryRow("keyed").Scan(&sym, &px); err != nil || sym != "a" || px != 2 {
+		t.Errorf("keyed table: got %v %v %v", sym, px, err)
+	}
+	if _, err := db.Query("atom"); err != ErrNotTable {
+		t.Errorf("expected %v, got %v", ErrNotTable, err)
+	}
+	if _, err := db.Exec("fail"); err == nil || err.Error() != "type" {
+		t.Errorf("expected q error, got %v", err)
+	}
+	if _, err := db.Exec("atom"); err != nil {
+		t.Errorf("connection unusable after q error: %v", err)
+	}
+	if _, err := db.Begin(); err != ErrNoTx {
+		t.Errorf("expected %v, got %v", ErrNoTx, err)
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	var valueTests = []struct {
+		col      *K
+		expected interface{}
+	}{
+		{&K{KB, NONE, []bool{true}}, true},
+		{&K{KG, NONE, []byte{7}}, int64(7)},
+		{&K{KC, NONE, "x"}, "x"},
+		{&K{KC, NONE, " "}, nil},
+		{IntV([]int32{Ni}), nil},
+		{RealV([]float32{1}), float64(1)},
+		{&K{KM, NONE, []Month{NewMonth(2020, 3)}}, time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{DateV([]time.Time{Nd}), nil},
+		{TimespanV([]time.Duration{time.Second}), int64(time.Second)},
+		{&K{KT, NONE, []Time{TimeOf(time.Minute)}}, int64(time.Minute)},
+		{&K{KT, NONE, []Time{Nt}}, nil},
+		{&K{UU, NONE, []GUID{{}}}, nil},
+		{NewList(Float(1.5)), 1.5},
+		{NewList(&K{-KC, NONE, byte('a')}), "a"},
+		{NewList(Null(K0)), nil},
+		{NewList(LongV([]int64{1, 2})), "1 2"},
+	}
+	for _, tt := range valueTests {
+		if v := sqlValue(tt.col, 0); !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("sqlValue(%v): expected %#v, got %#v", tt.col, tt.expected, v)
+		}
+	}
+}
+
+func TestSQLDecodeOptions(t *testing.T) {
+	dsn := serveSQL(t, func(req *K) (*K, error) {
+		return NewTable([]string{"x"}, []*K{LongV(make([]int64, 100))}), nil
+	})
+	db, err := sql.Open("kdb", dsn+"?maxveclen=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Query("t"); !errors.Is(err, ErrVectorTooLong) {
+		t.Fatalf("expected %v, got %v", ErrVectorTooLong, err)
+	}
+	var c *sqlConn
+	conn, _ := db.Conn(context.Background())
+	defer conn.Close()
+	conn.Raw(func(dc interface{}) error {
+		c = dc.(*sqlConn)
+		return nil
+	})
+	if c.bad {
+		t.Error("expected fresh connection after response over limits")
+	}
+}
+
+func TestSQLConnectTimeout(t *testing.T) {
+	// server accepts connections but never answers handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	d := &Driver{}
+	for _, dsn := range []string{"kdb://" + ln.Addr().String() + "?timeout=50ms", "kdb://" + ln.Addr().String() + "?tls=true&timeout=50ms"} {
+		c, err := d.OpenConnector(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if _, err := c.Connect(context.Background()); err == nil || time.Since(start) > time.Second {
+			t.Errorf("%s: expected timeout, got %v after %v", dsn, err, time.Since(start))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		c.(*connector).timeout = 0
+		start = time.Now()
+		if _, err := c.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+			t.Errorf("%s: expected %v, got %v after %v", dsn, context.DeadlineExceeded, err, time.Since(start))
+		}
+		cancel()
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"time"
 	"unicode"
 )
@@ -56,6 +57,13 @@ const (
 	KV int8 = 18 // 4 second    int
 	KT int8 = 19 // 4 time      int     millisecond
 
+	// enumerations and mapped types
+	KE0      int8 = 20 // first enumeration type, each domain gets its own type in 20..76
+	KEMAX    int8 = 76 // last enumeration type
+	KANYMAP  int8 = 77 // anymap - not available in IPC
+	KMAPPED  int8 = 78 // 78..96 - mapped list of lists of type t-77, not available in IPC
+	KNESTSYM int8 = 97 // nested sym enum - not available in IPC
+
 	// table,dict
 	XT int8 = 98  //   pointer to dictionary containing string keys(column names) and values
 	XD int8 = 99  //   2 element generic list with 0 as keys and 1 as values
@@ -106,14 +114,14 @@ const Nj int64 = math.MinInt64
 // Wj is a long infinity
 const Wj int64 = math.MaxInt64
 
-// Ne is a real nil
-var Ne = float32(math.NaN())
+// Ne is a real nil, bit pattern matches q's 0Ne
+var Ne = math.Float32frombits(0xffc00000)
 
 // We is a real infinity
 var We = float32(math.Inf(+1))
 
-// Nf is a double nil
-var Nf = math.NaN()
+// Nf is a double nil, bit pattern matches q's 0n
+var Nf = math.Float64frombits(0xfff8000000000000)
 
 // Wf is a double infinity
 var Wf = math.Inf(+1)
@@ -211,12 +219,14 @@ func NewFunc(ctx, body string) *K {
 // Dictionaries = number of keys
 // Tables = number of rows
 func (k *K) Len() int {
-	if k.Type < K0 || k.Type >= KFUNC {
+	if k.Type == XD || k.Type == SD {
+		return k.Data.(Dict).Key.Len()
+	} else if k.Type < K0 || k.Type >= KFUNC {
 		return 1
 	} else if k.Type >= K0 && k.Type <= KT {
 		return reflect.ValueOf(k.Data).Len()
-	} else if k.Type == XD {
-		return k.Data.(Dict).Key.Len()
+	} else if isEnum(k.Type) {
+		return len(k.Data.(Enum).Index)
 	} else if k.Type == XT {
 		return k.Data.(Table).Data[0].Len()
 	} else {
@@ -224,6 +234,86 @@ func (k *K) Len() int {
 	}
 }
 
+// IsNull reports whether k is a null atom
+func (k *K) IsNull() bool {
+	switch k.Type {
+	case -UU:
+		return k.Data.(GUID) == GUID{}
+	case -KH:
+		return k.Data.(int16) == Nh
+	case -KI:
+		return k.Data.(int32) == Ni
+	case -KJ:
+		return k.Data.(int64) == Nj
+	case -KE:
+		return math.IsNaN(float64(k.Data.(float32)))
+	case -KF:
+		return math.IsNaN(k.Data.(float64))
+	case -KC:
+		return k.Data.(byte) == ' '
+	case -KS:
+		return k.Data.(string) == ""
+	case -KP, -KD:
+		return k.Data.(time.Time).IsZero()
+	case -KZ:
+		return k.Data.(Datetime).IsNull()
+	case -KN:
+		return k.Data.(time.Duration) == Nn
+	case -KM:
+		return k.Data.(Month) == Nm
+	case -KU:
+		return k.Data.(Minute) == Nu
+	case -KV:
+		return k.Data.(Second) == Nv
+	case -KT:
+		return k.Data.(Time) == Nt
+	}
+	return false
+}
+
+// IsInf reports whether k is a positive or negative infinity atom
+func (k *K) IsInf() bool {
+	switch k.Type {
+	case -KH:
+		v := k.Data.(int16)
+		return v == Wh || v == -Wh
+	case -KI:
+		v := k.Data.(int32)
+		return v == Wi || v == -Wi
+	case -KJ:
+		v := k.Data.(int64)
+		return v == Wj || v == -Wj
+	case -KE:
+		return math.IsInf(float64(k.Data.(float32)), 0)
+	case -KF:
+		return math.IsInf(k.Data.(float64), 0)
+	case -KP:
+		v := toTimestamp(k.Data.(time.Time))
+		return v == Wj || v == -Wj
+	case -KD:
+		v := toDate(k.Data.(time.Time))
+		return v == Wi || v == -Wi
+	case -KZ:
+		return math.IsInf(float64(k.Data.(Datetime)), 0)
+	case -KN:
+		v := k.Data.(time.Duration)
+		return v == Wn || v == -Wn
+	case -KM:
+		v := k.Data.(Month)
+		return v == Wm || v == -Wm
+	case -KU:
+		v := k.Data.(Minute)
+		return v == Wu || v == -Wu
+	case -KV:
+		v := k.Data.(Second)
+		return v == Wv || v == -Wv
+	case -KT:
+		v := k.Data.(Time)
+		return v == Wt || v == -Wt
+	}
+	return false
+}
+
 // Index returns i'th element of K structure
 func (k *K) Index(i int) interface{} {
 	if k.Type < K0 || k.Type > XT {
@@ -240,6 +330,10 @@ func (k *K) Index(i int) interface{} {
 	if k.Type >= K0 && k.Type <= KT {
 		return reflect.ValueOf(k.Data).Index(i).Interface()
 	}
+	if isEnum(k.Type) {
+		e := k.Data.(Enum)
+		return Enum{e.Domain, e.Index[i : i+1]}
+	}
 	// case for table
 	// need to return dict with header
 	if k.Type != XT {
@@ -258,7 +352,7 @@ var adverbs = []string{106: "'", 107: "/", 108: "\\", 109: "':", 110: "/:", 111:
 
 // String converts K structure to string
 func (k K) String() string {
-	if k.Type < K0 {
+	if k.Type < K0 && !isEnum(-k.Type) {
 		return fmt.Sprint(k.Data)
 	}
 	if k.Type > K0 && k.Type <= KT {
@@ -280,26 +374,16 @@ func (k K) String() string {
 		return buf.String()
 	case XD:
 		return attrPrint[k.Attr] + k.Data.(Dict).String()
+	case SD:
+		return attrPrint[SORTED] + k.Data.(Dict).String()
 	case XT:
 		return attrPrint[k.Attr] + k.Data.(Table).String()
-	case KFUNC:
-		return k.Data.(Function).Body
-	case KFUNCUP:
-		return unaryops[k.Data.(byte)]
-	case KFUNCBP:
-		return binaryops[k.Data.(byte)]
-	case KFUNCTR:
-		return ternaryops[k.Data.(byte)]
-	case KPROJ, KCOMP:
-		list := k.Data.([]*K)
-		var buf bytes.Buffer
-		for _, l := range list {
-			buf.WriteString(l.String())
-		}
-		return buf.String()
-	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
-		return k.Data.(*K).String() + adverbs[k.Type]
+	case KFUNC, KFUNCUP, KFUNCBP, KFUNCTR, KPROJ, KCOMP, KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
+		return FormatLiteral(&k)
 	default:
+		if isEnum(k.Type) {
+			return attrPrint[k.Attr] + k.Data.(Enum).String()
+		}
 		return "unknown"
 	}
 }
@@ -310,43 +394,23 @@ var ErrBadMsg = errors.New("Bad Message")
 // ErrBadHeader to indicate invalid header
 var ErrBadHeader = errors.New("Bad header")
 
-// ErrSyncRequest cannot process sync requests
-var ErrSyncRequest = errors.New("nosyncrequest")
-
-// Epoch offset for Q time. Q epoch starts on 1st Jan 2000
-var qEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
-
-// Month represents a month type in kdb
-type Month int32
-
-func (m Month) String() string {
-	return fmt.Sprintf("%v.%02vm", 2000+int(m/12), int(m)%12)
-}
-
-// Minute represents a minute type in kdb
-type Minute time.Time
+// ErrMsgTooLarge to indicate message or vector payload above DecodeOptions.MaxMsgSize
+var ErrMsgTooLarge = errors.New("message too large")
 
-func (m Minute) String() string {
-	time := time.Time(m)
-	return fmt.Sprintf("%02v:%02v", time.Hour(), time.Minute())
+// ErrVectorTooLong to indicate vector length above DecodeOptions.MaxVectorLen
+var ErrVectorTooLong = errors.New("vector too long")
 
-}
-
-// Second represents a second type in kdb - hh:mm:ss
-type Second time.Time
+// ErrTooDeep to indicate nesting above DecodeOptions.MaxDepth
+var ErrTooDeep = errors.New("nesting too deep")
 
-func (s Second) String() string {
-	time := time.Time(s)
-	return fmt.Sprintf("%02v:%02v:%02v", time.Hour(), time.Minute(), time.Second())
-}
+// ErrSymbolTooLong to indicate symbol length above DecodeOptions.MaxSymbolLen
+var ErrSymbolTooLong = errors.New("symbol too long")
 
-// Time represents time type in kdb - hh:mm:ss.SSS
-type Time time.Time
+// ErrSyncRequest cannot process sync requests
+var ErrSyncRequest = errors.New("nosyncrequest")
 
-func (t Time) String() string {
-	time := time.Time(t)
-	return fmt.Sprintf("%02v:%02v:%02v.%03v", time.Hour(), time.Minute(), time.Second(), time.Nanosecond()/1000000)
-}
+// Epoch offset for Q time. Q epoch starts on 1st Jan 2000
+var qEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
 
 // Table represents table type in kdb
 type Table struct {
@@ -408,6 +472,102 @@ func NewDict(k, v *K) *K {
 	return &K{XD, NONE, Dict{k, v}}
 }
 
+// NewSortedDict constructs K sorted dict from k,v slices.
+// Keys should be sorted, lookups in sorted dict act as a step function.
+func NewSortedDict(k, v *K) *K {
+	return &K{SD, NONE, Dict{k, v}}
+}
+
+// StepIndex returns index of the last key not greater than x, same as q's bin.
+// Keys must be a sorted vector and x of the same Go type as key elements.
+// Returns -1 if x is less than the first key or can't be compared with keys.
+func (d Dict) StepIndex(x interface{}) int {
+	if d.Key == nil || d.Key.Type <= K0 || d.Key.Type > KT || d.Key.Type == KC {
+		return -1
+	}
+	keys := reflect.ValueOf(d.Key.Data)
+	xv := reflect.ValueOf(x)
+	if xv.Type() != keys.Type().Elem() {
+		return -1
+	}
+	return sort.Search(keys.Len(), func(i int) bool {
+		return compareValues(keys.Index(i), xv) > 0
+	}) - 1
+}
+
+// Step looks up x in d same as q does for sorted dicts:
+// returns value for the last key not greater than x, or null if there is none.
+func (d Dict) Step(x interface{}) *K {
+	i := d.StepIndex(x)
+	if i < 0 {
+		if d.Value.Type > K0 && d.Value.Type <= KT {
+			return Null(-d.Value.Type)
+		}
+		return Null(K0)
+	}
+	switch {
+	case d.Value.Type == K0:
+		return d.Value.Data.([]*K)[i]
+	case d.Value.Type == KC:
+		return &K{-KC, NONE, d.Value.Data.(string)[i]}
+	case d.Value.Type > K0 && d.Value.Type <= KT:
+		return &K{-d.Value.Type, NONE, d.Value.Index(i)}
+	case d.Value.Type == XT:
+		return d.Value.Index(i).(*K)
+	}
+	return Null(K0)
+}
+
+// Null returns null atom of type t, e.g. 0Ni for -KI.
+// For types without null, e.g. generic list, returns generic null (::).
+func Null(t int8) *K {
+	if t > 0 {
+		t = -t
+	}
+	var x interface{}
+	switch t {
+	case -KB:
+		x = false
+	case -UU:
+		x = GUID{}
+	case -KG:
+		x = byte(0)
+	case -KH:
+		x = Nh
+	case -KI:
+		x = Ni
+	case -KJ:
+		x = Nj
+	case -KE:
+		x = Ne
+	case -KF:
+		x = Nf
+	case -KC:
+		x = byte(' ')
+	case -KS:
+		x = ""
+	case -KP:
+		x = Np
+	case -KM:
+		x = Nm
+	case -KD:
+		x = Nd
+	case -KZ:
+		x = Nz
+	case -KN:
+		x = Nn
+	case -KU:
+		x = Nu
+	case -KV:
+		x = Nv
+	case -KT:
+		x = Nt
+	default:
+		return &K{KFUNCUP, NONE, byte(0)}
+	}
+	return &K{t, NONE, x}
+}
+
 // String
 func (d Dict) String() string {
 	return fmt.Sprintf("%v!%v", d.Key.Data, d.Value.Data)
@@ -436,8 +596,13 @@ func UnmarshalDict(t Dict, v interface{}) error {
 		if !fv.IsValid() {
 			continue
 		}
-		if fv.CanSet() && reflect.TypeOf(val).AssignableTo(fv.Type()) {
+		if !fv.CanSet() {
+			continue
+		}
+		if reflect.TypeOf(val).AssignableTo(fv.Type()) {
 			fv.Set(reflect.ValueOf(val))
+		} else if g, ok := val.(GUID); ok {
+			assignGUID(fv, g)
 		}
 	}
 	return nil
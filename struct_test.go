@@ -0,0 +1,61 @@
+package kdb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeSortedAttrDict(t *testing.T) {
+	// dicts with `s# attribute are sent as sorted dicts
+	buf := new(bytes.Buffer)
+	d := &K{XD, SORTED, Dict{&K{KS, SORTED, []string{"a", "b"}}, IntV([]int32{2, 3})}}
+	if err := Encode(buf, ASYNC, d); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), SortedDictBytes) {
+		t.Errorf("Encoded sorted dict incorrectly. Expected '%v', got '%v'", SortedDictBytes, buf.Bytes())
+	}
+}
+
+func TestStep(t *testing.T) {
+	d := NewSortedDict(&K{KJ, SORTED, []int64{1, 5, 10}}, SymbolV([]string{"a", "b", "c"})).Data.(Dict)
+	var stepTests = []struct {
+		x        interface{}
+		expected *K
+	}{
+		{int64(0), Symbol("")},
+		{int64(1), Symbol("a")},
+		{int64(4), Symbol("a")},
+		{int64(5), Symbol("b")},
+		{int64(100), Symbol("c")},
+		{int32(5), Symbol("")},
+	}
+	for _, tt := range stepTests {
+		if r := d.Step(tt.x); !reflect.DeepEqual(r, tt.expected) {
+			t.Errorf("Step(%v): expected %v, got %v", tt.x, tt.expected, r)
+		}
+	}
+
+	times := []time.Time{qEpoch, qEpoch.Add(time.Hour)}
+	d = NewSortedDict(&K{KP, SORTED, times}, NewList(Int(1), Long(2))).Data.(Dict)
+	if r := d.Step(qEpoch.Add(time.Minute)); !reflect.DeepEqual(r, Int(1)) {
+		t.Errorf("Step on timestamps: expected 1i, got %v", r)
+	}
+	if r := d.Step(qEpoch.Add(-time.Minute)); r.Type != KFUNCUP {
+		t.Errorf("Step before first key: expected (::), got %v", r)
+	}
+}
+
+func TestNull(t *testing.T) {
+	for _, typ := range []int8{KB, UU, KG, KH, KI, KJ, KE, KF, KC, KS, KP, KM, KD, KZ, KN, KU, KV, KT} {
+		n := Null(typ)
+		if n.Type != -typ || Validate(n) != nil {
+			t.Errorf("Null(%d) = %#v", typ, n)
+		}
+		if typ != KB && typ != KG && !n.IsNull() {
+			t.Errorf("Null(%d) = %v is not null", typ, n)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package kdb
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SymDomain is domain of enumerated symbols, such as sym file of a database.
+// It isn't safe for concurrent use.
+type SymDomain struct {
+	Name    string   // domain name, e.g. sym
+	Symbols []string // symbols in order of their indices
+	index   map[string]int64
+}
+
+// NewSymDomain creates domain name with given symbols
+func NewSymDomain(name string, symbols []string) *SymDomain {
+	return &SymDomain{Name: name, Symbols: symbols}
+}
+
+// LoadSymDomain reads domain from sym file, the domain is named after the file
+func LoadSymDomain(path string) (*SymDomain, error) {
+	k, err := readFileObject(path)
+	if err != nil {
+		return nil, err
+	}
+	if k.Type != KS {
+		return nil, ErrBadFile
+	}
+	return NewSymDomain(filepath.Base(path), k.Data.([]string)), nil
+}
+
+// Index returns position of symbol s in domain
+func (d *SymDomain) Index(s string) (int64, bool) {
+	if d.index == nil {
+		d.index = make(map[string]int64, len(d.Symbols))
+		for i, x := range d.Symbols {
+			if _, ok := d.index[x]; !ok {
+				d.index[x] = int64(i)
+			}
+		}
+	}
+	i, ok := d.index[s]
+	return i, ok
+}
+
+// Enumerate returns indices of syms in domain, appending symbols which aren't there yet
+func (d *SymDomain) Enumerate(syms []string) []int64 {
+	res := make([]int64, len(syms))
+	for i, s := range syms {
+		j, ok := d.Index(s)
+		if !ok {
+			j = int64(len(d.Symbols))
+			d.Symbols = append(d.Symbols, s)
+			d.index[s] = j
+		}
+		res[i] = j
+	}
+	return res
+}
+
+// Resolve replaces enumerations over this domain in k, including those nested in lists, dicts and tables,
+// with symbols. Other objects are returned as they are.
+func (d *SymDomain) Resolve(k *K) (*K, error) {
+	switch {
+	case isEnum(k.Type) || isEnum(-k.Type):
+		if e, ok := k.Data.(Enum); ok && e.Domain != d.Name {
+			return k, nil
+		}
+		return k.Resolve(d.Symbols)
+	case k.Type == K0:
+		list := k.Data.([]*K)
+		res := make([]*K, len(list))
+		for i, v := range list {
+			r, err := d.Resolve(v)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = r
+		}
+		return &K{K0, k.Attr, res}, nil
+	case k.Type == XD || k.Type == SD:
+		dict := k.Data.(Dict)
+		key, err := d.Resolve(dict.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.Resolve(dict.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &K{k.Type, k.Attr, Dict{key, value}}, nil
+	case k.Type == XT:
+		t := k.Data.(Table)
+		data, err := d.Resolve(&K{K0, NONE, t.Data})
+		if err != nil {
+			return nil, err
+		}
+		return &K{XT, k.Attr, Table{t.Columns, data.Data.([]*K)}}, nil
+	}
+	return k, nil
+}
+
+// Append appends symbols from position n onwards to sym file, which must hold the first n symbols,
+// creating the file when n is zero. Compressed sym file is rewritten whole, uncompressed.
+func (d *SymDomain) Append(path string, n int) error {
+	if n == 0 {
+		return writeObject(path, SymbolV(d.Symbols))
+	}
+	if n == len(d.Symbols) {
+		return nil
+	}
+	return appendSymbols(path, n, d.Symbols)
+}
+
+// loadOrCreateSymDomain reads sym file, or returns empty domain if it doesn't exist or is empty,
+// as when just created to be locked
+func loadOrCreateSymDomain(path string) (*SymDomain, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Size() == 0 {
+		return NewSymDomain(filepath.Base(path), nil), nil
+	}
+	d, err := LoadSymDomain(path)
+	if os.IsNotExist(err) {
+		return NewSymDomain(filepath.Base(path), nil), nil
+	}
+	return d, err
+}
@@ -0,0 +1,92 @@
+package kdb
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSymDomain(t *testing.T) {
+	dir := t.TempDir()
+	writeTestObject(t, filepath.Join(dir, "sym"), SymbolV([]string{"a", "b"}))
+	writeTestObject(t, filepath.Join(dir, "bad"), Long(1))
+	d, err := LoadSymDomain(filepath.Join(dir, "sym"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "sym" || !reflect.DeepEqual(d.Symbols, []string{"a", "b"}) {
+		t.Errorf("unexpected domain %v %v", d.Name, d.Symbols)
+	}
+	if _, err := LoadSymDomain(filepath.Join(dir, "bad")); err != ErrBadFile {
+		t.Errorf("expected %v, got %v", ErrBadFile, err)
+	}
+	if _, err := LoadSymDomain(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestSymDomainEnumerate(t *testing.T) {
+	d := NewSymDomain("sym", []string{"a", "b", "a"})
+	if i, ok := d.Index("a"); !ok || i != 0 {
+		t.Errorf("Index(a): expected 0, got %v %v", i, ok)
+	}
+	if _, ok := d.Index("c"); ok {
+		t.Error("Index(c): expected not found")
+	}
+	index := d.Enumerate([]string{"b", "c", "a", "c", ""})
+	if !reflect.DeepEqual(index, []int64{1, 3, 0, 3, 4}) {
+		t.Errorf("unexpected indices %v", index)
+	}
+	if !reflect.DeepEqual(d.Symbols, []string{"a", "b", "a", "c", ""}) {
+		t.Errorf("unexpected symbols %v", d.Symbols)
+	}
+}
+
+func TestSymDomainResolve(t *testing.T) {
+	d := NewSymDomain("sym", []string{"a", "b"})
+	other := Enumeration(KE0, "dom", []int64{0})
+	var resolveTests = []struct {
+		k        *K
+		expected *K
+	}{
+		{Enumeration(KE0, "sym", []int64{1, 0, Nj}), SymbolV([]string{"b", "a", ""})},
+		{&K{-KE0, NONE, Enum{"sym", []int64{1}}}, Symbol("b")},
+		{other, other},
+		{Long(1), Long(1)},
+		{NewList(Long(1), Enumeration(KE0, "sym", []int64{0})), NewList(Long(1), SymbolV([]string{"a"}))},
+		{NewDict(Enumeration(KE0, "sym", []int64{0, 1}), LongV([]int64{1, 2})),
+			NewDict(SymbolV([]string{"a", "b"}), LongV([]int64{1, 2}))},
+		{NewTable([]string{"s", "x"}, []*K{Enumeration(KE0, "sym", []int64{1}), LongV([]int64{1})}),
+			NewTable([]string{"s", "x"}, []*K{SymbolV([]string{"b"}), LongV([]int64{1})})},
+	}
+	for _, tt := range resolveTests {
+		r, err := d.Resolve(tt.k)
+		if err != nil {
+			t.Errorf("Resolve(%v): %v", tt.k, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, tt.expected) {
+			t.Errorf("Resolve(%v): expected %v, got %v", tt.k, tt.expected, r)
+		}
+	}
+}
+
+func TestSymDomainAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sym")
+	d := NewSymDomain("sym", nil)
+	d.Enumerate([]string{"a", "b"})
+	if err := d.Append(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	d.Enumerate([]string{"b", "c"})
+	if err := d.Append(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSymDomain(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Symbols, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected symbols %v", loaded.Symbols)
+	}
+}
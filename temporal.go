@@ -0,0 +1,295 @@
+package kdb
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Temporal nulls and infinities.
+// Types represented by time.Time use zero time.Time as null,
+// while other temporal types use the same underlying values as q.
+
+// Np is a timestamp nil
+var Np = time.Time{}
+
+// Wp is a timestamp infinity
+var Wp = qEpoch.Add(time.Duration(Wj))
+
+// Nd is a date nil
+var Nd = time.Time{}
+
+// Wd is a date infinity
+var Wd = qEpoch.AddDate(0, 0, int(Wi))
+
+// Nz is a datetime nil
+var Nz = Datetime(Nf)
+
+// Wz is a datetime infinity
+var Wz = Datetime(Wf)
+
+// Nn is a timespan nil
+const Nn = time.Duration(Nj)
+
+// Wn is a timespan infinity
+const Wn = time.Duration(Wj)
+
+// Nm is a month nil
+const Nm = Month(Ni)
+
+// Wm is a month infinity
+const Wm = Month(Wi)
+
+// Nu is a minute nil
+const Nu = Minute(Ni)
+
+// Wu is a minute infinity
+const Wu = Minute(Wi)
+
+// Nv is a second nil
+const Nv = Second(Ni)
+
+// Wv is a second infinity
+const Wv = Second(Wi)
+
+// Nt is a time nil
+const Nt = Time(Ni)
+
+// Wt is a time infinity
+const Wt = Time(Wi)
+
+// Timestamp wraps time.Time as K timestamp
+func Timestamp(x time.Time) *K {
+	return &K{-KP, NONE, x}
+}
+
+// TimestampV wraps time.Time slice as K timestamp vector
+func TimestampV(x []time.Time) *K {
+	return &K{KP, NONE, x}
+}
+
+// Timespan wraps time.Duration as K timespan
+func Timespan(x time.Duration) *K {
+	return &K{-KN, NONE, x}
+}
+
+// TimespanV wraps time.Duration slice as K timespan vector
+func TimespanV(x []time.Duration) *K {
+	return &K{KN, NONE, x}
+}
+
+// Month represents a month type in kdb - months since 2000.01
+type Month int32
+
+// NewMonth returns Month for given year and month
+func NewMonth(year int, month time.Month) Month {
+	return Month((year-2000)*12 + int(month) - 1)
+}
+
+// MonthOf returns Month containing t
+func MonthOf(t time.Time) Month {
+	return NewMonth(t.Year(), t.Month())
+}
+
+// Year returns year of non-null m
+func (m Month) Year() int {
+	y := int(m) / 12
+	if m < 0 && m%12 != 0 {
+		y--
+	}
+	return 2000 + y
+}
+
+// Month returns month of the year of non-null m
+func (m Month) Month() time.Month {
+	mm := int(m) % 12
+	if mm < 0 {
+		mm += 12
+	}
+	return time.Month(mm + 1)
+}
+
+// Time returns first instant of m in UTC
+func (m Month) Time() time.Time {
+	return time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (m Month) String() string {
+	switch m {
+	case Nm:
+		return "0Nm"
+	case Wm:
+		return "0Wm"
+	case -Wm:
+		return "-0Wm"
+	}
+	return fmt.Sprintf("%04d.%02dm", m.Year(), int(m.Month()))
+}
+
+// Datetime represents deprecated datetime type in kdb - fractional days since 2000.01.01.
+// Value is kept as is to allow lossless roundtrip.
+type Datetime float64
+
+// DatetimeOf returns Datetime closest to t. Zero time maps to null.
+func DatetimeOf(t time.Time) Datetime {
+	if t.IsZero() {
+		return Nz
+	}
+	secs := t.Unix() - qEpoch.Unix()
+	return Datetime((float64(secs) + float64(t.Nanosecond())/1e9) / 86400)
+}
+
+// Time returns d as time.Time with millisecond precision as displayed by q.
+// Null maps to zero time.
+func (d Datetime) Time() time.Time {
+	if math.IsNaN(float64(d)) {
+		return time.Time{}
+	}
+	ms := math.Round(float64(d) * 86400000)
+	secs := math.Floor(ms / 1000)
+	return time.Unix(qEpoch.Unix()+int64(secs), int64(ms-secs*1000)*int64(time.Millisecond)).UTC()
+}
+
+// IsNull reports whether d is null
+func (d Datetime) IsNull() bool {
+	return math.IsNaN(float64(d))
+}
+
+func (d Datetime) String() string {
+	switch {
+	case math.IsNaN(float64(d)):
+		return "0Nz"
+	case math.IsInf(float64(d), 1):
+		return "0Wz"
+	case math.IsInf(float64(d), -1):
+		return "-0Wz"
+	}
+	return d.Time().Format("2006.01.02T15:04:05.000")
+}
+
+// Minute represents a minute type in kdb - minutes since midnight
+type Minute int32
+
+// MinuteOf returns Minute for duration since midnight d, truncated to whole minutes
+func MinuteOf(d time.Duration) Minute {
+	return Minute(d / time.Minute)
+}
+
+// Duration returns non-null m as duration since midnight
+func (m Minute) Duration() time.Duration {
+	return time.Duration(m) * time.Minute
+}
+
+func (m Minute) String() string {
+	switch m {
+	case Nu:
+		return "0Nu"
+	case Wu:
+		return "0Wu"
+	}
+	sign, v := signAbs(int64(m))
+	return fmt.Sprintf("%s%02d:%02d", sign, v/60, v%60)
+}
+
+// Second represents a second type in kdb - hh:mm:ss, seconds since midnight
+type Second int32
+
+// SecondOf returns Second for duration since midnight d, truncated to whole seconds
+func SecondOf(d time.Duration) Second {
+	return Second(d / time.Second)
+}
+
+// Duration returns non-null s as duration since midnight
+func (s Second) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+func (s Second) String() string {
+	switch s {
+	case Nv:
+		return "0Nv"
+	case Wv:
+		return "0Wv"
+	}
+	sign, v := signAbs(int64(s))
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, v/3600, v/60%60, v%60)
+}
+
+// Time represents time type in kdb - hh:mm:ss.SSS, milliseconds since midnight
+type Time int32
+
+// TimeOf returns Time for duration since midnight d, truncated to whole milliseconds
+func TimeOf(d time.Duration) Time {
+	return Time(d / time.Millisecond)
+}
+
+// Duration returns non-null t as duration since midnight
+func (t Time) Duration() time.Duration {
+	return time.Duration(t) * time.Millisecond
+}
+
+func (t Time) String() string {
+	switch t {
+	case Nt:
+		return "0Nt"
+	case Wt:
+		return "0Wt"
+	}
+	sign, v := signAbs(int64(t))
+	return fmt.Sprintf("%s%02d:%02d:%02d.%03d", sign, v/3600000, v/60000%60, v/1000%60, v%1000)
+}
+
+func signAbs(v int64) (string, int64) {
+	if v < 0 {
+		return "-", -v
+	}
+	return "", v
+}
+
+// fromTimestamp converts nanoseconds since 2000.01.01 to time.Time
+func fromTimestamp(ns int64) time.Time {
+	if ns == Nj {
+		return Np
+	}
+	return qEpoch.Add(time.Duration(ns))
+}
+
+// toTimestamp converts time.Time to nanoseconds since 2000.01.01.
+// Times outside of timestamp range are clamped to infinities.
+func toTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return Nj
+	}
+	ns := int64(t.Sub(qEpoch))
+	if ns == Nj {
+		return -Wj
+	}
+	return ns
+}
+
+// fromDate converts days since 2000.01.01 to time.Time
+func fromDate(d int32) time.Time {
+	if d == Ni {
+		return Nd
+	}
+	return qEpoch.AddDate(0, 0, int(d))
+}
+
+// toDate converts time.Time to days since 2000.01.01
+// Dates outside of date range are clamped to infinities.
+func toDate(t time.Time) int32 {
+	if t.IsZero() {
+		return Ni
+	}
+	secs := t.Unix() - qEpoch.Unix()
+	days := secs / 86400
+	if secs%86400 < 0 {
+		days--
+	}
+	if days >= int64(Wi) {
+		return Wi
+	} else if days <= -int64(Wi) {
+		return -Wi
+	}
+	return int32(days)
+}
@@ -0,0 +1,209 @@
+package kdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func atomBytes(t int8, payload ...byte) []byte {
+	msg := []byte{0x01, 0x00, 0x00, 0x00, byte(9 + len(payload)), 0x00, 0x00, 0x00, byte(t)}
+	return append(msg, payload...)
+}
+
+var temporalNullTests = []struct {
+	desc     string
+	input    *K
+	expected []byte
+	null     bool
+	inf      bool
+}{
+	{"0Np", &K{-KP, NONE, Np}, atomBytes(-KP, 0, 0, 0, 0, 0, 0, 0, 0x80), true, false},
+	{"0Wp", &K{-KP, NONE, Wp}, atomBytes(-KP, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"-0Wp", &K{-KP, NONE, qEpoch.Add(-time.Duration(Wj))}, atomBytes(-KP, 0x01, 0, 0, 0, 0, 0, 0, 0x80), false, true},
+	{"0Nd", &K{-KD, NONE, Nd}, atomBytes(-KD, 0, 0, 0, 0x80), true, false},
+	{"0Wd", &K{-KD, NONE, Wd}, atomBytes(-KD, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"-0Wd", &K{-KD, NONE, qEpoch.AddDate(0, 0, -int(Wi))}, atomBytes(-KD, 0x01, 0, 0, 0x80), false, true},
+	{"1999.12.31", &K{-KD, NONE, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)}, atomBytes(-KD, 0xff, 0xff, 0xff, 0xff), false, false},
+	{"0Nz", &K{-KZ, NONE, Nz}, atomBytes(-KZ, 0, 0, 0, 0, 0, 0, 0xf8, 0xff), true, false},
+	{"0Wz", &K{-KZ, NONE, Wz}, atomBytes(-KZ, 0, 0, 0, 0, 0, 0, 0xf0, 0x7f), false, true},
+	{"0Nn", &K{-KN, NONE, Nn}, atomBytes(-KN, 0, 0, 0, 0, 0, 0, 0, 0x80), true, false},
+	{"0Wn", &K{-KN, NONE, Wn}, atomBytes(-KN, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"0Nm", &K{-KM, NONE, Nm}, atomBytes(-KM, 0, 0, 0, 0x80), true, false},
+	{"0Wm", &K{-KM, NONE, Wm}, atomBytes(-KM, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"0Nu", &K{-KU, NONE, Nu}, atomBytes(-KU, 0, 0, 0, 0x80), true, false},
+	{"0Wu", &K{-KU, NONE, Wu}, atomBytes(-KU, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"00:00", &K{-KU, NONE, Minute(0)}, atomBytes(-KU, 0, 0, 0, 0), false, false},
+	{"0Nv", &K{-KV, NONE, Nv}, atomBytes(-KV, 0, 0, 0, 0x80), true, false},
+	{"0Wv", &K{-KV, NONE, Wv}, atomBytes(-KV, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"0Nt", &K{-KT, NONE, Nt}, atomBytes(-KT, 0, 0, 0, 0x80), true, false},
+	{"0Wt", &K{-KT, NONE, Wt}, atomBytes(-KT, 0xff, 0xff, 0xff, 0x7f), false, true},
+	{"-0Wt", &K{-KT, NONE, -Wt}, atomBytes(-KT, 0x01, 0, 0, 0x80), false, true},
+	{"00:00:00.000", &K{-KT, NONE, Time(0)}, atomBytes(-KT, 0, 0, 0, 0), false, false},
+}
+
+func TestTemporalNulls(t *testing.T) {
+	for _, tt := range temporalNullTests {
+		buf := new(bytes.Buffer)
+		if err := Encode(buf, ASYNC, tt.input); err != nil {
+			t.Errorf("Encoding '%s' failed: %s", tt.desc, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), tt.expected) {
+			t.Errorf("Encoded '%s' incorrectly. Expected '%v', got '%v'", tt.desc, tt.expected, buf.Bytes())
+		}
+		d, _, err := Decode(bufio.NewReader(bytes.NewReader(tt.expected)))
+		if err != nil {
+			t.Errorf("Decoding '%s' failed: %s", tt.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, tt.input) && !(d.Type == tt.input.Type && d.IsNull() && tt.input.IsNull()) {
+			t.Errorf("Decoded '%s' incorrectly. Expected '%#v', got '%#v'", tt.desc, tt.input, d)
+		}
+		if d.IsNull() != tt.null || d.IsInf() != tt.inf {
+			t.Errorf("'%s': expected IsNull %v IsInf %v, got %v %v", tt.desc, tt.null, tt.inf, d.IsNull(), d.IsInf())
+		}
+	}
+}
+
+func TestTemporalNullVectors(t *testing.T) {
+	var vectors = []*K{
+		{KP, NONE, []time.Time{Np, Wp, qEpoch, TimestampAsTime}},
+		{KD, NONE, []time.Time{Nd, Wd, qEpoch, DateAsTime}},
+		{KZ, NONE, []Datetime{Nz, Wz, 0, DatetimeOf(DatetimeAsTime)}},
+		{KN, NONE, []time.Duration{Nn, Wn, 0}},
+		{KM, NONE, []Month{Nm, Wm, 0}},
+		{KU, NONE, []Minute{Nu, Wu, 0}},
+		{KV, NONE, []Second{Nv, Wv, 0}},
+		{KT, NONE, []Time{Nt, Wt, 0}},
+	}
+	for _, v := range vectors {
+		buf := new(bytes.Buffer)
+		if err := Encode(buf, ASYNC, v); err != nil {
+			t.Errorf("Encoding %v failed: %s", v, err)
+			continue
+		}
+		encoded := buf.Bytes()
+		d, _, err := Decode(bufio.NewReader(bytes.NewReader(encoded)))
+		if err != nil {
+			t.Errorf("Decoding %v failed: %s", v, err)
+			continue
+		}
+		// datetime nulls are NaN and never DeepEqual
+		if v.Type != KZ && !reflect.DeepEqual(d, v) {
+			t.Errorf("Roundtrip failed. Expected '%#v', got '%#v'", v, d)
+		}
+		buf.Reset()
+		Encode(buf, ASYNC, d)
+		if !bytes.Equal(buf.Bytes(), encoded) {
+			t.Errorf("Roundtrip of %v failed. Expected %v, got %v", v, encoded, buf.Bytes())
+		}
+	}
+}
+
+func TestTemporalString(t *testing.T) {
+	var stringTests = []struct {
+		v        interface{ String() string }
+		expected string
+	}{
+		{Nm, "0Nm"},
+		{Month(161), "2013.06m"},
+		{Month(-1), "1999.12m"},
+		{Nz, "0Nz"},
+		{DatetimeOf(DatetimeAsTime), "2013.06.10T22:03:49.713"},
+		{Nu, "0Nu"},
+		{Minute(1282), "21:22"},
+		{Wv, "0Wv"},
+		{Second(76922), "21:22:02"},
+		{Nt, "0Nt"},
+		{Time(78817963), "21:53:37.963"},
+		{Time(-1), "-00:00:00.001"},
+	}
+	for _, tt := range stringTests {
+		if tt.v.String() != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, tt.v.String())
+		}
+	}
+}
+
+func TestMonth(t *testing.T) {
+	for _, m := range []Month{-25, -13, -12, -1, 0, 1, 11, 12, 161} {
+		if NewMonth(m.Year(), m.Month()) != m {
+			t.Errorf("Month %d: %d.%d doesn't roundtrip", m, m.Year(), m.Month())
+		}
+		if MonthOf(m.Time()) != m {
+			t.Errorf("Month %d: %v doesn't roundtrip", m, m.Time())
+		}
+	}
+	if m := NewMonth(2013, time.June); m != 161 {
+		t.Errorf("Expected 161 for 2013.06m, got %d", m)
+	}
+}
+
+func TestTimeOfDay(t *testing.T) {
+	d := 21*time.Hour + 53*time.Minute + 37*time.Second + 963*time.Millisecond
+	if v := TimeOf(d); v != 78817963 || v.Duration() != d {
+		t.Errorf("TimeOf(%v) = %d", d, v)
+	}
+	if v := SecondOf(d); v.Duration() != d.Truncate(time.Second) {
+		t.Errorf("SecondOf(%v) = %d", d, v)
+	}
+	if v := MinuteOf(d); v != 1313 || v.Duration() != d.Truncate(time.Minute) {
+		t.Errorf("MinuteOf(%v) = %d", d, v)
+	}
+}
+
+// rawVector builds ipc message containing vector of type t with raw element bytes
+func rawVector(t int8, n int, elems []byte) []byte {
+	msg := []byte{0x01, 0x00, 0x00, 0x00, 0, 0, 0, 0, byte(t), 0x00, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(msg[10:], uint32(n))
+	msg = append(msg, elems...)
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(msg)))
+	return msg
+}
+
+func TestTemporalBitExact(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	edges := map[int][][]byte{
+		4: {{0, 0, 0, 0x80}, {0xff, 0xff, 0xff, 0x7f}, {0x01, 0, 0, 0x80}, {0, 0, 0, 0}, {0xff, 0xff, 0xff, 0xff}},
+		8: {{0, 0, 0, 0, 0, 0, 0, 0x80}, {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, {0x01, 0, 0, 0, 0, 0, 0, 0x80},
+			{0, 0, 0, 0, 0, 0, 0xf8, 0xff}, {0, 0, 0, 0, 0, 0, 0xf0, 0x7f}, {0, 0, 0, 0, 0, 0, 0xf0, 0xff}, {0x01, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, typ := range []int8{KP, KM, KD, KZ, KN, KU, KV, KT} {
+		size := typeSize[typ]
+		var elems []byte
+		for _, e := range edges[size] {
+			elems = append(elems, e...)
+		}
+		for i := 0; i < 100; i++ {
+			e := make([]byte, size)
+			rnd.Read(e)
+			elems = append(elems, e...)
+		}
+		n := len(elems) / size
+		vec := rawVector(typ, n, elems)
+		atoms := make([][]byte, n)
+		for i := range atoms {
+			atoms[i] = atomBytes(-typ, elems[i*size:(i+1)*size]...)
+		}
+		for _, msg := range append(atoms, vec) {
+			d, _, err := Decode(bufio.NewReader(bytes.NewReader(msg)))
+			if err != nil {
+				t.Errorf("Decoding %v failed: %s", msg, err)
+				continue
+			}
+			buf := new(bytes.Buffer)
+			if err := writeData(buf, binary.LittleEndian, d); err != nil {
+				t.Errorf("Encoding %v failed: %s", d, err)
+				continue
+			}
+			if !bytes.Equal(buf.Bytes(), msg[8:]) {
+				t.Errorf("Type %d doesn't roundtrip. Expected %v, got %v", typ, msg[8:], buf.Bytes())
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package kdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ValidationError describes first inconsistency found by Validate.
+// Path locates offending node starting from the root "k", e.g.
+// k[1] for list element, k.key/k.value for dict parts,
+// k.price for table column and k.f for function wrapped by an adverb.
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid K at " + e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns underlying error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrBadAttr to indicate attribute which doesn't hold for the data
+var ErrBadAttr = errors.New("attribute does not hold")
+
+// Go types expected in K.Data for each q type which can be encoded
+var validTypes = map[int8]reflect.Type{
+	-KB: reflect.TypeOf(false),
+	-UU: reflect.TypeOf(GUID{}),
+	-KG: reflect.TypeOf(byte(0)),
+	-KH: reflect.TypeOf(int16(0)),
+	-KI: reflect.TypeOf(int32(0)),
+	-KJ: reflect.TypeOf(int64(0)),
+	-KE: reflect.TypeOf(float32(0)),
+	-KF: reflect.TypeOf(float64(0)),
+	-KC: reflect.TypeOf(byte(0)),
+	-KS: reflect.TypeOf(""),
+	-KP: reflect.TypeOf(time.Time{}),
+	-KM: reflect.TypeOf(Month(0)),
+	-KD: reflect.TypeOf(time.Time{}),
+	-KZ: reflect.TypeOf(Datetime(0)),
+	-KN: reflect.TypeOf(time.Duration(0)),
+	-KU: reflect.TypeOf(Minute(0)),
+	-KV: reflect.TypeOf(Second(0)),
+	-KT: reflect.TypeOf(Time(0)),
+	KB:  reflect.TypeOf([]bool{}),
+	UU:  reflect.TypeOf([]GUID{}),
+	KG:  reflect.TypeOf([]byte{}),
+	KH:  reflect.TypeOf([]int16{}),
+	KI:  reflect.TypeOf([]int32{}),
+	KJ:  reflect.TypeOf([]int64{}),
+	KE:  reflect.TypeOf([]float32{}),
+	KF:  reflect.TypeOf([]float64{}),
+	KC:  reflect.TypeOf(""),
+	KS:  reflect.TypeOf([]string{}),
+	KP:  reflect.TypeOf([]time.Time{}),
+	KM:  reflect.TypeOf([]Month{}),
+	KD:  reflect.TypeOf([]time.Time{}),
+	KZ:  reflect.TypeOf([]Datetime{}),
+	KN:  reflect.TypeOf([]time.Duration{}),
+	KU:  reflect.TypeOf([]Minute{}),
+	KV:  reflect.TypeOf([]Second{}),
+	KT:  reflect.TypeOf([]Time{}),
+
+	K0:         reflect.TypeOf([]*K{}),
+	XD:         reflect.TypeOf(Dict{}),
+	SD:         reflect.TypeOf(Dict{}),
+	XT:         reflect.TypeOf(Table{}),
+	KFUNC:      reflect.TypeOf(Function{}),
+	KFUNCUP:    reflect.TypeOf(byte(0)),
+	KFUNCBP:    reflect.TypeOf(byte(0)),
+	KFUNCTR:    reflect.TypeOf(byte(0)),
+	KPROJ:      reflect.TypeOf([]*K{}),
+	KCOMP:      reflect.TypeOf([]*K{}),
+	KEACH:      reflect.TypeOf(&K{}),
+	KOVER:      reflect.TypeOf(&K{}),
+	KSCAN:      reflect.TypeOf(&K{}),
+	KPRIOR:     reflect.TypeOf(&K{}),
+	KEACHRIGHT: reflect.TypeOf(&K{}),
+	KEACHLEFT:  reflect.TypeOf(&K{}),
+}
+
+// Validate checks that k can be encoded and will be accepted by q as is:
+// Data of every node matches its Type, dict keys and values and
+// table columns have matching lengths and attributes hold for the data.
+// Returned error is *ValidationError pointing to the offending node.
+func Validate(k *K) error {
+	return validate(k, "k")
+}
+
+func invalid(path string, format string, a ...interface{}) error {
+	return &ValidationError{path, fmt.Errorf(format, a...)}
+}
+
+func validate(k *K, path string) error {
+	if k == nil {
+		return invalid(path, "nil object")
+	}
+	if k.Type == KERR {
+		if _, ok := k.Data.(error); !ok {
+			return invalid(path, "expected error, got %T", k.Data)
+		}
+		return nil
+	}
+	if isEnum(k.Type) || isEnum(-k.Type) {
+		e, ok := k.Data.(Enum)
+		if !ok {
+			return invalid(path, "type %d expects kdb.Enum, got %T", k.Type, k.Data)
+		}
+		if k.Type < 0 && len(e.Index) != 1 {
+			return invalid(path, "enum atom with %d indices", len(e.Index))
+		}
+		if k.Attr < NONE || k.Attr > GROUPED || (k.Type < 0 && k.Attr != NONE) {
+			return invalid(path, "unknown attribute %d", k.Attr)
+		}
+		return nil
+	}
+	t, ok := validTypes[k.Type]
+	if !ok {
+		return invalid(path, "unsupported type %d", k.Type)
+	}
+	if k.Data == nil || reflect.TypeOf(k.Data) != t {
+		return invalid(path, "type %d expects %v, got %T", k.Type, t, k.Data)
+	}
+	if k.Attr < NONE || k.Attr > GROUPED {
+		return invalid(path, "unknown attribute %d", k.Attr)
+	}
+	if k.Attr != NONE && (k.Type < K0 || k.Type >= KFUNC) {
+		return invalid(path, "attribute %s on type %d", attrPrint[k.Attr], k.Type)
+	}
+	switch k.Type {
+	case K0, KPROJ, KCOMP:
+		for i, v := range k.Data.([]*K) {
+			if err := validate(v, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case XD, SD:
+		d := k.Data.(Dict)
+		if err := validate(d.Key, path+".key"); err != nil {
+			return err
+		}
+		if err := validate(d.Value, path+".value"); err != nil {
+			return err
+		}
+		if !isList(d.Key) || !isList(d.Value) {
+			return invalid(path, "dict keys and values should be lists or tables")
+		}
+		if d.Key.Len() != d.Value.Len() {
+			return invalid(path, "%d keys but %d values", d.Key.Len(), d.Value.Len())
+		}
+		if (k.Type == SD || k.Attr == SORTED) && !holds(d.Key, SORTED) {
+			return &ValidationError{path + ".key", ErrBadAttr}
+		}
+	case XT:
+		tbl := k.Data.(Table)
+		if len(tbl.Columns) != len(tbl.Data) {
+			return invalid(path, "%d column names but %d columns", len(tbl.Columns), len(tbl.Data))
+		}
+		if len(tbl.Columns) == 0 {
+			return invalid(path, "table without columns")
+		}
+		seen := make(map[string]bool, len(tbl.Columns))
+		for i, c := range tbl.Columns {
+			if c == "" || seen[c] {
+				return invalid(path, "empty or duplicate column name '%s'", c)
+			}
+			seen[c] = true
+			cpath := path + "." + c
+			if err := validate(tbl.Data[i], cpath); err != nil {
+				return err
+			}
+			if !isList(tbl.Data[i]) || tbl.Data[i].Type >= XT {
+				return invalid(cpath, "column should be a list, got type %d", tbl.Data[i].Type)
+			}
+			if n, n0 := tbl.Data[i].Len(), tbl.Data[0].Len(); n != n0 {
+				return invalid(cpath, "column length %d differs from %d of column %s", n, n0, tbl.Columns[0])
+			}
+		}
+		if k.Attr != NONE && k.Attr != SORTED {
+			return invalid(path, "attribute %s on table", attrPrint[k.Attr])
+		}
+	case KEACH, KOVER, KSCAN, KPRIOR, KEACHRIGHT, KEACHLEFT:
+		return validate(k.Data.(*K), path+".f")
+	}
+	if k.Type > K0 && k.Type <= KT && !holds(k, k.Attr) {
+		return &ValidationError{path, ErrBadAttr}
+	}
+	return nil
+}
+
+// isList reports whether k is a vector, generic list or a table
+func isList(k *K) bool {
+	return k.Type >= K0 && k.Type <= KT || k.Type == XT
+}
+
+// holds reports whether attribute a is satisfied by vector k.
+// Only vectors of comparable values are checked, generic lists and tables are accepted as is.
+func holds(k *K, a Attr) bool {
+	if k.Type <= K0 || k.Type > KT || a == NONE || a == GROUPED {
+		return true
+	}
+	v := reflect.ValueOf(k.Data)
+	if k.Type == KC {
+		v = reflect.ValueOf([]byte(k.Data.(string)))
+	}
+	n := v.Len()
+	switch a {
+	case SORTED:
+		for i := 1; i < n; i++ {
+			if compareAt(v, i-1, i) > 0 {
+				return false
+			}
+		}
+	case UNIQUE, PARTED:
+		seen := make(map[interface{}]bool, n)
+		for i := 0; i < n; i++ {
+			e := v.Index(i).Interface()
+			// for parted only the first element of each run has to be new
+			if a == PARTED && i > 0 && compareAt(v, i-1, i) == 0 {
+				continue
+			}
+			if seen[e] {
+				return false
+			}
+			seen[e] = true
+		}
+	}
+	return true
+}
+
+// compareAt compares i'th and j'th elements of a vector
+func compareAt(v reflect.Value, i, j int) int {
+	return compareValues(v.Index(i), v.Index(j))
+}
+
+// compareValues compares two vector elements of the same type in q order
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		} else if b.Bool() {
+			return -1
+		}
+		return 1
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, y := a.Int(), b.Int()
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, y := a.Uint(), b.Uint()
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		// nulls sort first in q
+		if math.IsNaN(x) || math.IsNaN(y) {
+			if math.IsNaN(x) && math.IsNaN(y) {
+				return 0
+			} else if math.IsNaN(x) {
+				return -1
+			}
+			return 1
+		}
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case reflect.String:
+		if a.String() < b.String() {
+			return -1
+		} else if a.String() > b.String() {
+			return 1
+		}
+		return 0
+	case reflect.Array:
+		x, y := make([]byte, a.Len()), make([]byte, b.Len())
+		reflect.Copy(reflect.ValueOf(x), a)
+		reflect.Copy(reflect.ValueOf(y), b)
+		return bytes.Compare(x, y)
+	}
+	if x, ok := a.Interface().(time.Time); ok {
+		y := b.Interface().(time.Time)
+		if x.Before(y) {
+			return -1
+		} else if x.After(y) {
+			return 1
+		}
+	}
+	return 0
+}
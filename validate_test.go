@@ -0,0 +1,95 @@
+package kdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var validateTests = []struct {
+	desc  string
+	input *K
+	path  string // empty if valid
+}{
+	{"int", Int(1), ""},
+	{"wrong atom data", &K{-KI, NONE, int64(1)}, "k"},
+	{"wrong vector data", &K{KJ, NONE, []int32{1}}, "k"},
+	{"nil data", &K{KS, NONE, nil}, "k"},
+	{"unknown type", &K{42, NONE, 1}, "k"},
+	{"attribute on atom", &K{-KJ, SORTED, int64(1)}, "k"},
+	{"sorted", &K{KJ, SORTED, []int64{1, 2, 2, 3}}, ""},
+	{"unsorted", &K{KJ, SORTED, []int64{1, 3, 2}}, "k"},
+	{"sorted floats with null", &K{KF, SORTED, []float64{Nf, 1, 2}}, ""},
+	{"sorted symbols", &K{KS, SORTED, []string{"a", "b", "c"}}, ""},
+	{"unsorted string", &K{KC, SORTED, "ba"}, "k"},
+	{"unique", &K{KS, UNIQUE, []string{"a", "c", "b"}}, ""},
+	{"not unique", &K{KS, UNIQUE, []string{"a", "c", "a"}}, "k"},
+	{"parted", &K{KI, PARTED, []int32{2, 2, 1, 3, 3}}, ""},
+	{"not parted", &K{KI, PARTED, []int32{2, 1, 2}}, "k"},
+	{"grouped", &K{KI, GROUPED, []int32{2, 1, 2}}, ""},
+	{"list element", NewList(Int(1), &K{-KS, NONE, 1}), "k[1]"},
+	{"dict", NewDict(SymbolV([]string{"a", "b"}), IntV([]int32{2, 3})), ""},
+	{"dict length mismatch", NewDict(SymbolV([]string{"a", "b"}), IntV([]int32{2})), "k"},
+	{"dict with atom key", NewDict(Symbol("a"), IntV([]int32{2})), "k"},
+	{"dict value", NewDict(SymbolV([]string{"a"}), NewList(&K{KI, NONE, []int64{2}})), "k.value[0]"},
+	{"unsorted sorted dict", &K{XD, SORTED, Dict{SymbolV([]string{"b", "a"}), IntV([]int32{2, 3})}}, "k.key"},
+	{"table", NewTable([]string{"a", "b"}, []*K{IntV([]int32{2}), IntV([]int32{3})}), ""},
+	{"table column length", NewTable([]string{"a", "b"}, []*K{IntV([]int32{2}), IntV([]int32{3, 4})}), "k.b"},
+	{"table column count", NewTable([]string{"a", "b"}, []*K{IntV([]int32{2})}), "k"},
+	{"table atom column", NewTable([]string{"a"}, []*K{Int(2)}), "k.a"},
+	{"table duplicate column", NewTable([]string{"a", "a"}, []*K{IntV([]int32{2}), IntV([]int32{3})}), "k"},
+	{"table column attribute", NewTable([]string{"a"}, []*K{{KI, SORTED, []int32{3, 2}}}), "k.a"},
+	{"keyed table", NewDict(NewTable([]string{"a"}, []*K{IntV([]int32{2})}), NewTable([]string{"b"}, []*K{IntV([]int32{3})})), ""},
+	{"keyed table column", NewDict(NewTable([]string{"a"}, []*K{IntV([]int32{2})}), NewTable([]string{"b", "c"}, []*K{IntV([]int32{3}), IntV([]int32{3, 4})})), "k.value.c"},
+	{"keyed table rows", NewDict(NewTable([]string{"a"}, []*K{IntV([]int32{2})}), NewTable([]string{"b"}, []*K{IntV([]int32{3, 4})}).Data.(Table).Data[0]), "k"},
+	{"function", NewFunc("", "{x+y}"), ""},
+	{"projection", &K{KPROJ, NONE, []*K{NewFunc("", "{x+y}"), Symbol("a"), {-KB, NONE, 1}}}, "k[2]"},
+	{"adverb", &K{KEACH, NONE, &K{KFUNCBP, NONE, 1}}, "k.f"},
+	{"error", Error(errors.New("type")), ""},
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range validateTests {
+		err := Validate(tt.input)
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("Validate '%s' failed: %v", tt.desc, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("Validate '%s': expected *ValidationError, got %v", tt.desc, err)
+			continue
+		}
+		if verr.Path != tt.path {
+			t.Errorf("Validate '%s': expected path %s, got %s (%v)", tt.desc, tt.path, verr.Path, err)
+		}
+	}
+}
+
+func TestValidateEncodingTests(t *testing.T) {
+	// everything encoded correctly should pass validation
+	for _, tt := range encodingTests {
+		buf := new(bytes.Buffer)
+		if err := Encode(buf, ASYNC, tt.input); err != nil || !bytes.Equal(buf.Bytes(), tt.expected) {
+			continue
+		}
+		if err := Validate(tt.input); err != nil {
+			t.Errorf("Validate '%s' failed: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestEncodeWithValidation(t *testing.T) {
+	buf := new(bytes.Buffer)
+	bad := NewTable([]string{"a", "b"}, []*K{IntV([]int32{2}), IntV([]int32{3, 4})})
+	err := EncodeWithOptions(buf, ASYNC, bad, EncodeOptions{Validate: true})
+	if err == nil || buf.Len() != 0 {
+		t.Errorf("Expected validation error without output, got %v and %d bytes", err, buf.Len())
+	}
+	err = EncodeWithOptions(buf, ASYNC, Int(1), EncodeOptions{Validate: true})
+	if err != nil || !bytes.Equal(buf.Bytes(), IntBytes) {
+		t.Errorf("Encoding with validation failed: %v", err)
+	}
+}
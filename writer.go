@@ -0,0 +1,252 @@
+package kdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSymLocked is returned when sym file stays locked by another writer for longer than SymLockTimeout
+var ErrSymLocked = errors.New("sym file is locked")
+
+// SymLockTimeout is how long writers wait for lock of sym file
+var SymLockTimeout = 10 * time.Second
+
+// WriteSplayed writes table t splayed into dir, in the layout described for SplayedTable.
+// Symbol columns are enumerated against symFile, new symbols are appended to it under lock.
+func WriteSplayed(dir string, t Table, symFile string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var sym *SymDomain
+	for i, c := range t.Data {
+		if c.Type == KS && sym == nil {
+			var err error
+			if sym, err = enumerate(symFile, symbolColumns(t)); err != nil {
+				return err
+			}
+		}
+		if err := writeColumn(filepath.Join(dir, t.Columns[i]), c, sym); err != nil {
+			return err
+		}
+	}
+	return writeObject(filepath.Join(dir, ".d"), SymbolV(t.Columns))
+}
+
+// WritePartition saves t as table in partition p of database db the same way as q's .Q.dpft[db;p;f;table].
+// Rows are sorted by column f, which is moved to the front and gets parted attribute,
+// symbol columns are enumerated against sym file of db.
+func WritePartition(db string, p *K, f string, table string, t Table) error {
+	if p.Type != -KD && p.Type != -KM && p.Type != -KI {
+		return ErrPartitionType
+	}
+	i := indexOf(t.Columns, f)
+	if i < 0 {
+		return ErrNoColumn
+	}
+	t = sortRows(t, i)
+	cols := append([]string{f}, append(append([]string{}, t.Columns[:i]...), t.Columns[i+1:]...)...)
+	data := append([]*K{t.Data[i]}, append(append([]*K{}, t.Data[:i]...), t.Data[i+1:]...)...)
+	data[0] = &K{data[0].Type, PARTED, data[0].Data}
+	body, _ := atomBody(p.Type, p.Data)
+	return WriteSplayed(filepath.Join(db, body, table), Table{cols, data}, filepath.Join(db, "sym"))
+}
+
+func symbolColumns(t Table) [][]string {
+	var syms [][]string
+	for _, c := range t.Data {
+		if c.Type == KS {
+			syms = append(syms, c.Data.([]string))
+		}
+	}
+	return syms
+}
+
+// writeColumn writes single column file, symbols are stored as indices into sym
+func writeColumn(path string, k *K, sym *SymDomain) error {
+	switch {
+	case k.Type == KS:
+		index := sym.Enumerate(k.Data.([]string))
+		return writeMapped(path, KE0, k.Attr, len(index), index)
+	case k.Type >= KB && k.Type <= KT:
+		var buf bytes.Buffer
+		if err := writeData(&buf, binary.LittleEndian, k); err != nil {
+			return err
+		}
+		// drop type, attribute and length of IPC vector
+		return writeMapped(path, k.Type, k.Attr, k.Len(), buf.Bytes()[6:])
+	case k.Type == K0:
+		if t := nestedType(k); t != 0 {
+			return writeNested(path, t, k.Data.([]*K))
+		}
+	}
+	return writeObject(path, k)
+}
+
+// nestedType returns type of vectors in list if all of them are of the same simple type, otherwise 0
+func nestedType(k *K) int8 {
+	list := k.Data.([]*K)
+	if len(list) == 0 {
+		return 0
+	}
+	t := list[0].Type
+	for _, v := range list {
+		if v.Type != t {
+			return 0
+		}
+	}
+	if t < KB || t > KT || t == KS {
+		return 0
+	}
+	return t
+}
+
+// writeNested writes list of vectors of type t as end offsets and file with items
+func writeNested(path string, t int8, list []*K) error {
+	var items bytes.Buffer
+	offsets := make([]int64, len(list))
+	var end int64
+	for i, v := range list {
+		var buf bytes.Buffer
+		if err := writeData(&buf, binary.LittleEndian, v); err != nil {
+			return err
+		}
+		items.Write(buf.Bytes()[6:])
+		end += int64(v.Len())
+		offsets[i] = end
+	}
+	if err := os.WriteFile(path+"#", items.Bytes(), 0644); err != nil {
+		return err
+	}
+	return writeMapped(path, KANYMAP+t, NONE, len(offsets), offsets)
+}
+
+// writeMapped writes mapped vector file with n elements of type t
+func writeMapped(path string, t int8, attr Attr, n int, data interface{}) error {
+	var buf bytes.Buffer
+	buf.Write(append(append([]byte{}, mappedPrefix...), byte(t), byte(attr), 0, 0, 0, 0))
+	binary.Write(&buf, binary.LittleEndian, int64(n))
+	if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func writeObject(path string, k *K) error {
+	buf, err := WriteToBuffer(k)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// enumerate loads domain of sym file and extends both with symbols of columns which aren't there yet
+func enumerate(symFile string, columns [][]string) (*SymDomain, error) {
+	unlock, err := lockFile(symFile)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	sym, err := loadOrCreateSymDomain(symFile)
+	if err != nil {
+		return nil, err
+	}
+	n := len(sym.Symbols)
+	for _, c := range columns {
+		sym.Enumerate(c)
+	}
+	if err := sym.Append(symFile, n); err != nil {
+		return nil, err
+	}
+	return sym, nil
+}
+
+// appendSymbols appends symbols from position n onwards to serialized symbol vector of length n in place,
+// creating the file if n is zero. Compressed file can't be patched and is rewritten.
+func appendSymbols(symFile string, n int, symbols []string) error {
+	if n == 0 {
+		return writeObject(symFile, SymbolV(symbols))
+	}
+	f, err := os.OpenFile(symFile, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	prefix := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(f, prefix); err != nil {
+		return err
+	}
+	if bytes.Equal(prefix, zipMagic) {
+		return writeObject(symFile, SymbolV(symbols))
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Join(symbols[n:], "\x00") + "\x00"); err != nil {
+		return err
+	}
+	// vector length follows 0xFF 0x01 prefix, type and attribute
+	count := make([]byte, 4)
+	binary.LittleEndian.PutUint32(count, uint32(len(symbols)))
+	if _, err := f.WriteAt(count, 4); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// sortRows returns t with rows stably sorted by column i
+func sortRows(t Table, i int) Table {
+	col := t.Data[i]
+	n := col.Len()
+	perm := make([]int, n)
+	for j := range perm {
+		perm[j] = j
+	}
+	var less func(a, b int) bool
+	switch v := col.Data.(type) {
+	case []string:
+		less = func(a, b int) bool { return v[a] < v[b] }
+	case string:
+		less = func(a, b int) bool { return v[a] < v[b] }
+	default:
+		less = func(a, b int) bool {
+			x, y := indexK(col, a), indexK(col, b)
+			xi, xf, isFloat := rawAtom(x.Type, x.Data)
+			yi, yf, _ := rawAtom(y.Type, y.Data)
+			if isFloat {
+				return xf < yf || xf != xf && yf == yf
+			}
+			return xi < yi
+		}
+	}
+	sort.SliceStable(perm, func(a, b int) bool { return less(perm[a], perm[b]) })
+	data := make([]*K, len(t.Data))
+	for j, c := range t.Data {
+		data[j] = permute(c, perm)
+	}
+	return Table{t.Columns, data}
+}
+
+// permute returns vector or list k with items reordered by perm
+func permute(k *K, perm []int) *K {
+	if s, ok := k.Data.(string); ok {
+		b := make([]byte, len(perm))
+		for i, p := range perm {
+			b[i] = s[p]
+		}
+		return &K{k.Type, NONE, string(b)}
+	}
+	v := reflect.ValueOf(k.Data)
+	res := reflect.MakeSlice(v.Type(), len(perm), len(perm))
+	for i, p := range perm {
+		res.Index(i).Set(v.Index(p))
+	}
+	return &K{k.Type, NONE, res.Interface()}
+}
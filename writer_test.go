@@ -0,0 +1,193 @@
+package kdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteSplayed(t *testing.T) {
+	db := t.TempDir()
+	tbl := NewTable([]string{"sym", "px", "time", "note", "flag", "c", "mixed"}, []*K{
+		SymbolV([]string{"b", "a", "b"}),
+		FloatV([]float64{1, 2, 3}),
+		TimestampV([]time.Time{qEpoch, Np, qEpoch.Add(time.Hour)}),
+		NewList(&K{KC, NONE, "ab"}, &K{KC, NONE, ""}, &K{KC, NONE, "c"}),
+		&K{KB, NONE, []bool{true, false, true}},
+		&K{KC, NONE, "xyz"},
+		NewList(Long(1), Symbol("x"), &K{K0, NONE, []*K{}}),
+	}).Data.(Table)
+	dir := filepath.Join(db, "t")
+	if err := WriteSplayed(dir, tbl, filepath.Join(db, "sym")); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenSplayed(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := s.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range k.Data.(Table).Data {
+		if !reflect.DeepEqual(c, tbl.Data[i]) {
+			t.Errorf("%s: expected %#v, got %#v", tbl.Columns[i], tbl.Data[i], c)
+		}
+	}
+	syms, err := ReadFromFile(filepath.Join(db, "sym"))
+	if err != nil || !reflect.DeepEqual(syms, SymbolV([]string{"b", "a"})) {
+		t.Errorf("expected sym file `b`a, got %v %v", syms, err)
+	}
+	if _, err := os.Stat(filepath.Join(db, "sym.lock")); !os.IsNotExist(err) {
+		t.Errorf("expected lock to be removed, got %v", err)
+	}
+}
+
+func TestWritePartition(t *testing.T) {
+	db := t.TempDir()
+	day1 := NewTable([]string{"px", "sym"}, []*K{FloatV([]float64{1, 2, 3}), SymbolV([]string{"c", "a", "c"})}).Data.(Table)
+	day2 := NewTable([]string{"px", "sym"}, []*K{FloatV([]float64{4, 5}), SymbolV([]string{"d", "a"})}).Data.(Table)
+	if err := WritePartition(db, date(2020, 1, 1), "sym", "trade", day1); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePartition(db, date(2020, 1, 2), "sym", "trade", day2); err != nil {
+		t.Fatal(err)
+	}
+	syms, err := ReadFromFile(filepath.Join(db, "sym"))
+	if err != nil || !reflect.DeepEqual(syms, SymbolV([]string{"a", "c", "d"})) {
+		t.Errorf("expected sym file `a`c`d, got %v %v", syms, err)
+	}
+	d, err := OpenDatabase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.Tables, []string{"trade"}) {
+		t.Errorf("expected trade table, got %v", d.Tables)
+	}
+	it := d.Scan("trade", nil, nil, "sym", "px")
+	var chunks []*K
+	for it.Next() {
+		chunks = append(chunks, it.Table())
+	}
+	expected := []*K{
+		NewTable([]string{"sym", "px"}, []*K{{KS, PARTED, []string{"a", "c", "c"}}, FloatV([]float64{2, 1, 3})}),
+		NewTable([]string{"sym", "px"}, []*K{{KS, PARTED, []string{"a", "d"}}, FloatV([]float64{5, 4})}),
+	}
+	if it.Err() != nil || !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v %v", expected, chunks, it.Err())
+	}
+	s, _ := d.Splayed(d.Partitions[0], "trade")
+	if !reflect.DeepEqual(s.Columns, []string{"sym", "px"}) {
+		t.Errorf("expected parted column first, got %v", s.Columns)
+	}
+	if err := WritePartition(db, Long(1), "sym", "trade", day1); err != ErrPartitionType {
+		t.Errorf("expected ErrPartitionType, got %v", err)
+	}
+	if err := WritePartition(db, date(2020, 1, 3), "size", "trade", day1); err != ErrNoColumn {
+		t.Errorf("expected ErrNoColumn, got %v", err)
+	}
+}
+
+func TestSymLock(t *testing.T) {
+	db := t.TempDir()
+	symFile := filepath.Join(db, "sym")
+	timeout := SymLockTimeout
+	SymLockTimeout = 20 * time.Millisecond
+	defer func() { SymLockTimeout = timeout }()
+	unlock, err := lockFile(symFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := NewTable([]string{"sym"}, []*K{SymbolV([]string{"a"})}).Data.(Table)
+	if err := WriteSplayed(filepath.Join(db, "t"), tbl, symFile); err != ErrSymLocked {
+		t.Errorf("expected ErrSymLocked, got %v", err)
+	}
+	unlock()
+	if err := WriteSplayed(filepath.Join(db, "t"), tbl, symFile); err != nil {
+		t.Errorf("write after unlock: %v", err)
+	}
+}
+
+func TestAppendCompressedSym(t *testing.T) {
+	db := t.TempDir()
+	symFile := filepath.Join(db, "sym")
+	buf, err := WriteToBuffer(SymbolV([]string{"a", "b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, symFile, zipBlocks(t, ZipGzip, 12, buf.Bytes()))
+	tbl := NewTable([]string{"sym"}, []*K{SymbolV([]string{"c", "a"})}).Data.(Table)
+	if err := WriteSplayed(filepath.Join(db, "t"), tbl, symFile); err != nil {
+		t.Fatal(err)
+	}
+	sym, err := LoadSymDomain(symFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(sym.Symbols, expected) {
+		t.Errorf("expected symbols %v, got %v", expected, sym.Symbols)
+	}
+}
+
+// TestWritePartitionMatchesQ writes the partitions testdata/gen.q writes with .Q.dpft and compares files byte for byte
+func TestWritePartitionMatchesQ(t *testing.T) {
+	qdb := qFixture(t, "hdb")
+	db := t.TempDir()
+	parts := []struct {
+		p   *K
+		tbl *K
+	}{
+		{date(2020, 1, 1), NewTable([]string{"px", "sym", "note"}, []*K{FloatV([]float64{1, 2, 3}),
+			SymbolV([]string{"c", "a", "c"}), NewList(&K{KC, NONE, "x"}, &K{KC, NONE, "yz"}, &K{KC, NONE, ""})})},
+		{date(2020, 1, 2), NewTable([]string{"px", "sym", "note"}, []*K{FloatV([]float64{4, 5}),
+			SymbolV([]string{"d", "a"}), NewList(&K{KC, NONE, "w"}, &K{KC, NONE, "vu"})})},
+	}
+	for _, p := range parts {
+		if err := WritePartition(db, p.p, "sym", "trade", p.tbl.Data.(Table)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := filepath.Walk(qdb, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(qdb, path)
+		expected, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		got, err := os.ReadFile(filepath.Join(db, rel))
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			return nil
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: expected % x, got % x", rel, expected, got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenDatabase(qdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := d.Scan("trade", nil, nil, "sym", "note")
+	var chunks []*K
+	for it.Next() {
+		chunks = append(chunks, it.Table())
+	}
+	expected := []*K{
+		NewTable([]string{"sym", "note"}, []*K{{KS, PARTED, []string{"a", "c", "c"}},
+			NewList(&K{KC, NONE, "yz"}, &K{KC, NONE, "x"}, &K{KC, NONE, ""})}),
+		NewTable([]string{"sym", "note"}, []*K{{KS, PARTED, []string{"a", "d"}},
+			NewList(&K{KC, NONE, "vu"}, &K{KC, NONE, "w"})}),
+	}
+	if it.Err() != nil || !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v %v", expected, chunks, it.Err())
+	}
+}
@@ -0,0 +1,281 @@
+package kdb
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Compressed files, as written by -19! or with .z.zd set, are read transparently by ReadFromFile
+// and splayed and partitioned readers. Such file starts with header:
+//
+//	"kxzipped", algorithm, log2 of logical block size, level, 5 reserved bytes,
+//	int64 uncompressed length, int64 number of blocks, int64 compressed size of every block
+//
+// followed by compressed blocks, each decompressing to logical block size except the last one.
+// Blocks of algorithm 1 hold output of q IPC compression without message header,
+// see Compress, algorithm 2 uses zlib, 3 snappy and 4 lz4 block format.
+
+// Compression algorithms of compressed files
+const (
+	ZipNone   = 0
+	ZipIPC    = 1
+	ZipGzip   = 2
+	ZipSnappy = 3
+	ZipLZ4    = 4
+)
+
+// ErrZipAlgorithm is returned for compressed files using unknown algorithm
+var ErrZipAlgorithm = errors.New("unsupported compression algorithm")
+
+var zipMagic = []byte("kxzipped")
+
+const zipHeaderSize = 32
+
+// zipHeader parses header of compressed file of size bytes and returns algorithm, logical block size,
+// uncompressed length and number of blocks
+func zipHeader(b []byte, size int64) (byte, uint64, uint64, uint64, error) {
+	if len(b) < zipHeaderSize || size < zipHeaderSize {
+		return 0, 0, 0, 0, ErrBadFile
+	}
+	alg, logBlock := b[8], b[9]
+	usize := binary.LittleEndian.Uint64(b[16:24])
+	nblocks := binary.LittleEndian.Uint64(b[24:32])
+	if logBlock < 12 || logBlock > 20 || nblocks > uint64(size-zipHeaderSize)/8 {
+		return 0, 0, 0, 0, ErrBadFile
+	}
+	blockSize := uint64(1) << logBlock
+	if usize > nblocks*blockSize || usize+blockSize <= nblocks*blockSize {
+		return 0, 0, 0, 0, ErrBadFile
+	}
+	return alg, blockSize, usize, nblocks, nil
+}
+
+// uncompressFile returns content of compressed file b
+func uncompressFile(b []byte) ([]byte, error) {
+	alg, blockSize, usize, nblocks, err := zipHeader(b, int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	sizes := b[zipHeaderSize : zipHeaderSize+nblocks*8]
+	data := b[zipHeaderSize+nblocks*8:]
+	res := make([]byte, 0, usize)
+	for i := uint64(0); i < nblocks; i++ {
+		size := binary.LittleEndian.Uint64(sizes[i*8:])
+		if size > uint64(len(data)) {
+			return nil, ErrBadFile
+		}
+		n := blockSize
+		if i == nblocks-1 {
+			n = usize - i*blockSize
+		}
+		block, err := uncompressBlock(alg, data[:size], int(n))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(block)) != n {
+			return nil, ErrBadFile
+		}
+		res = append(res, block...)
+		data = data[size:]
+	}
+	return res, nil
+}
+
+// uncompressFirstBlock returns content of the first block of compressed file f, whose header is b
+func uncompressFirstBlock(f *os.File, b []byte) ([]byte, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	alg, blockSize, usize, nblocks, err := zipHeader(b, fi.Size())
+	if err != nil || nblocks == 0 {
+		return nil, ErrBadFile
+	}
+	sizes := make([]byte, 8)
+	if _, err := f.ReadAt(sizes, zipHeaderSize); err != nil {
+		return nil, ErrBadFile
+	}
+	size := binary.LittleEndian.Uint64(sizes)
+	offset := zipHeaderSize + int64(nblocks)*8
+	if size > uint64(fi.Size()-offset) {
+		return nil, ErrBadFile
+	}
+	block := make([]byte, size)
+	if _, err := f.ReadAt(block, offset); err != nil {
+		return nil, err
+	}
+	n := blockSize
+	if usize < n {
+		n = usize
+	}
+	res, err := uncompressBlock(alg, block, int(n))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(res)) != n {
+		return nil, ErrBadFile
+	}
+	return res, nil
+}
+
+// uncompressBlock decompresses block of n bytes with algorithm alg
+func uncompressBlock(alg byte, b []byte, n int) ([]byte, error) {
+	switch alg {
+	case ZipNone:
+		return b, nil
+	case ZipIPC:
+		if len(b) < 4 || int(binary.LittleEndian.Uint32(b)) != n+8 {
+			return nil, ErrBadFile
+		}
+		res, err := Uncompress(b)
+		if err != nil {
+			return nil, err
+		}
+		return res[8:], nil
+	case ZipGzip:
+		r, err := zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, n)
+		if _, err := io.ReadFull(r, res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	case ZipSnappy:
+		return uncompressSnappy(b, n)
+	case ZipLZ4:
+		return uncompressLZ4(b, n)
+	}
+	return nil, ErrZipAlgorithm
+}
+
+// uncompressSnappy decodes snappy block expected to hold n bytes
+func uncompressSnappy(b []byte, n int) ([]byte, error) {
+	size, k := binary.Uvarint(b)
+	if k <= 0 || size != uint64(n) {
+		return nil, ErrBadFile
+	}
+	b = b[k:]
+	res := make([]byte, 0, n)
+	for len(b) > 0 {
+		tag := b[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag>>2) + 1
+			b = b[1:]
+			if extra := length - 60; extra > 0 {
+				if len(b) < extra {
+					return nil, ErrBadFile
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(b[i])
+				}
+				length++
+				b = b[extra:]
+			}
+			if length > len(b) || length > n-len(res) {
+				return nil, ErrBadFile
+			}
+			res = append(res, b[:length]...)
+			b = b[length:]
+			continue
+		case 1:
+			if len(b) < 2 {
+				return nil, ErrBadFile
+			}
+			length = int(tag>>2&7) + 4
+			offset = int(tag>>5)<<8 | int(b[1])
+			b = b[2:]
+		case 2:
+			if len(b) < 3 {
+				return nil, ErrBadFile
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(b[1:]))
+			b = b[3:]
+		case 3:
+			if len(b) < 5 {
+				return nil, ErrBadFile
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(b[1:]))
+			b = b[5:]
+		}
+		var err error
+		if res, err = copyMatch(res, offset, length, n); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// uncompressLZ4 decodes lz4 block expected to hold n bytes
+func uncompressLZ4(b []byte, n int) ([]byte, error) {
+	res := make([]byte, 0, n)
+	for len(b) > 0 {
+		token := b[0]
+		b = b[1:]
+		length, rest, err := lz4Length(b, int(token>>4))
+		if err != nil {
+			return nil, err
+		}
+		b = rest
+		if length > len(b) || length > n-len(res) {
+			return nil, ErrBadFile
+		}
+		res = append(res, b[:length]...)
+		b = b[length:]
+		if len(b) == 0 {
+			// the last sequence has literals only
+			break
+		}
+		if len(b) < 2 {
+			return nil, ErrBadFile
+		}
+		offset := int(binary.LittleEndian.Uint16(b))
+		if length, b, err = lz4Length(b[2:], int(token&15)); err != nil {
+			return nil, err
+		}
+		if res, err = copyMatch(res, offset, length+4, n); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// lz4Length reads length extension bytes following nibble value 15
+func lz4Length(b []byte, length int) (int, []byte, error) {
+	if length != 15 {
+		return length, b, nil
+	}
+	for {
+		if len(b) == 0 || length > maxPrealloc<<10 {
+			return 0, nil, ErrBadFile
+		}
+		c := b[0]
+		b = b[1:]
+		length += int(c)
+		if c != 255 {
+			return length, b, nil
+		}
+	}
+}
+
+// copyMatch appends length bytes starting offset bytes back, possibly overlapping
+func copyMatch(res []byte, offset, length, n int) ([]byte, error) {
+	if offset <= 0 || offset > len(res) || length > n-len(res) {
+		return nil, ErrBadFile
+	}
+	start := len(res) - offset
+	for i := 0; i < length; i++ {
+		res = append(res, res[start+i])
+	}
+	return res, nil
+}
@@ -0,0 +1,215 @@
+package kdb
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// zipFile lays out compressed file with given compressed blocks
+func zipFile(alg, logBlock byte, usize int, blocks [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(zipMagic)
+	buf.Write([]byte{alg, logBlock, 0, 0, 0, 0, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, []int64{int64(usize), int64(len(blocks))})
+	for _, b := range blocks {
+		binary.Write(&buf, binary.LittleEndian, int64(len(b)))
+	}
+	for _, b := range blocks {
+		buf.Write(b)
+	}
+	return buf.Bytes()
+}
+
+// zipBlocks splits data into blocks of 1<<logBlock bytes compressed with alg
+func zipBlocks(t *testing.T, alg, logBlock byte, data []byte) []byte {
+	var blocks [][]byte
+	for rest := data; len(rest) > 0; {
+		n := 1 << logBlock
+		if n > len(rest) {
+			n = len(rest)
+		}
+		chunk := rest[:n]
+		rest = rest[n:]
+		switch alg {
+		case ZipNone:
+			blocks = append(blocks, chunk)
+		case ZipIPC:
+			c := Compress(append(make([]byte, 8), chunk...))
+			if c[2] != 1 {
+				t.Fatal("test block doesn't compress")
+			}
+			blocks = append(blocks, c[8:])
+		case ZipGzip:
+			var buf bytes.Buffer
+			w := zlib.NewWriter(&buf)
+			w.Write(chunk)
+			w.Close()
+			blocks = append(blocks, buf.Bytes())
+		case ZipSnappy:
+			// literals only, each up to 64K with two byte length
+			b := make([]byte, binary.MaxVarintLen64)
+			b = b[:binary.PutUvarint(b, uint64(len(chunk)))]
+			b = append(b, 61<<2, byte(len(chunk)-1), byte((len(chunk)-1)>>8))
+			blocks = append(blocks, append(b, chunk...))
+		case ZipLZ4:
+			b := []byte{0xF0}
+			for l := len(chunk) - 15; ; l -= 255 {
+				if l < 255 {
+					b = append(b, byte(l))
+					break
+				}
+				b = append(b, 255)
+			}
+			blocks = append(blocks, append(b, chunk...))
+		}
+	}
+	return zipFile(alg, logBlock, len(data), blocks)
+}
+
+func TestReadCompressedFile(t *testing.T) {
+	longs := make([]int64, 1000)
+	for i := range longs {
+		longs[i] = int64(i % 10)
+	}
+	content := mappedFile(KJ, NONE, len(longs), longs)
+	dir := t.TempDir()
+	for _, alg := range []byte{ZipNone, ZipIPC, ZipGzip, ZipSnappy, ZipLZ4} {
+		path := filepath.Join(dir, "f")
+		writeTestFile(t, path, zipBlocks(t, alg, 12, content))
+		k, err := ReadFromFile(path)
+		if err != nil || !reflect.DeepEqual(k, LongV(longs)) {
+			t.Errorf("algorithm %d: got %v %v", alg, k, err)
+		}
+	}
+
+	writeTestObject(t, filepath.Join(dir, "t", ".d"), SymbolV([]string{"a"}))
+	writeTestFile(t, filepath.Join(dir, "t", "a"), zipBlocks(t, ZipGzip, 12, content))
+	s, err := OpenSplayed(filepath.Join(dir, "t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, err := s.Column("a"); err != nil || !reflect.DeepEqual(k, LongV(longs)) {
+		t.Errorf("compressed column: got %v %v", k, err)
+	}
+
+	good := zipBlocks(t, ZipNone, 12, content)
+	for i, b := range [][]byte{
+		good[:20],
+		good[:len(good)-1],
+		zipBlocks(t, 9, 12, content),
+		zipFile(ZipNone, 12, 5000, [][]byte{content[:4096]}),
+		zipFile(ZipNone, 11, 10, [][]byte{content[:10]}),
+	} {
+		if _, err := uncompressFile(b); err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+}
+
+func TestUncompressBlock(t *testing.T) {
+	var blockTests = []struct {
+		alg      byte
+		block    []byte
+		expected string
+	}{
+		{ZipSnappy, []byte{12, 0x08, 'a', 'b', 'c', 0x15, 0x03}, "abcabcabcabc"},
+		{ZipSnappy, []byte{6, 0x04, 'a', 'b', 0x0e, 0x02, 0x00}, "ababab"},
+		{ZipSnappy, []byte{5, 0x00, 'a', 0x0f, 0x01, 0x00, 0x00, 0x00}, "aaaaa"},
+		{ZipLZ4, []byte{0x35, 'a', 'b', 'c', 0x03, 0x00}, "abcabcabcabc"},
+		{ZipLZ4, []byte{0x10, 'a', 0x01, 0x00, 0x10, 'b'}, "aaaaab"},
+	}
+	for _, tt := range blockTests {
+		b, err := uncompressBlock(tt.alg, tt.block, len(tt.expected))
+		if err != nil || string(b) != tt.expected {
+			t.Errorf("uncompressBlock(%d, %v): expected %q, got %q %v", tt.alg, tt.block, tt.expected, b, err)
+		}
+	}
+	for _, tt := range []struct {
+		alg   byte
+		block []byte
+	}{
+		{ZipSnappy, []byte{3, 0x08, 'a', 'b'}},
+		{ZipSnappy, []byte{4, 0x00, 'a', 0x09, 0x02}},
+		{ZipLZ4, []byte{0x14, 'a', 0x05, 0x00}},
+		{ZipLZ4, []byte{0xF0}},
+	} {
+		if b, err := uncompressBlock(tt.alg, tt.block, 4); err == nil {
+			t.Errorf("uncompressBlock(%d, %v): expected error, got %q", tt.alg, tt.block, b)
+		}
+	}
+}
+
+func FuzzUncompressFile(f *testing.F) {
+	f.Add(zipFile(ZipSnappy, 12, 12, [][]byte{{12, 0x08, 'a', 'b', 'c', 0x15, 0x03}}))
+	f.Add(zipFile(ZipLZ4, 12, 12, [][]byte{{0x35, 'a', 'b', 'c', 0x03, 0x00}}))
+	f.Add(zipFile(ZipIPC, 12, 5, [][]byte{{0x0d, 0, 0, 0, 0, 'a', 'a', 'a', 'a', 'a'}}))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		uncompressFile(b)
+	})
+}
+
+// qFixture returns path of file generated by testdata/gen.q, skipping the test if it's missing
+func qFixture(t *testing.T, path ...string) string {
+	t.Helper()
+	p := filepath.Join(append([]string{"testdata"}, path...)...)
+	if _, err := os.Stat(p); err != nil {
+		t.Skipf("%s not generated, run q testdata/gen.q", p)
+	}
+	return p
+}
+
+func TestReadQCompressedFiles(t *testing.T) {
+	long := make([]int64, 20000)
+	for i := range long {
+		long[i] = int64(i)
+	}
+	var fixtureTests = []struct {
+		name     string
+		expected *K
+	}{
+		{"long", LongV(long)},
+		{"list", NewList(&K{KC, NONE, "text"}, Symbol("sym"), Float(1.5))},
+	}
+	for _, tt := range fixtureTests {
+		for alg := ZipIPC; alg <= ZipLZ4; alg++ {
+			path := qFixture(t, "zip", tt.name+strconv.Itoa(alg))
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(b, zipMagic) || b[8] != byte(alg) || b[9] != 16 {
+				t.Errorf("%s: unexpected header % x", path, b[:zipHeaderSize])
+			}
+			k, err := ReadFromFile(path)
+			if err != nil {
+				t.Errorf("ReadFromFile(%s): %v", path, err)
+				continue
+			}
+			if !reflect.DeepEqual(k, tt.expected) {
+				t.Errorf("ReadFromFile(%s): expected %v, got %v", path, tt.expected, k)
+			}
+		}
+	}
+}
+
+func TestOpenQCompressedSplayed(t *testing.T) {
+	s, err := OpenSplayed(qFixture(t, "zsplay", "trade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := s.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewTable([]string{"sym", "px", "size"}, []*K{
+		SymbolV([]string{"a", "b", "a"}), FloatV([]float64{1.5, 2, 3}), IntV([]int32{1, 2, Ni})})
+	if !reflect.DeepEqual(k, expected) {
+		t.Errorf("expected %v, got %v", expected, k)
+	}
+}